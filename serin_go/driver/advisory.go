@@ -0,0 +1,79 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "sync"
+)
+
+// AcquireAdvisoryLock waits for the session level advisory lock key with
+// pg_advisory_lock and returns a function that releases it. The lock is held
+// by a connection taken from the pool for the purpose, which only unlock
+// returns, so it is easy to release in a defer and cannot leak to another
+// user of the pool. If that connection drops, the server releases the lock;
+// unlock then reports the error. Calling unlock more than once is harmless.
+// When ctx is done before the lock is granted, the wait is cancelled and the
+// error returned.
+//
+// Session level locks last until released, across transactions. For a lock
+// that lasts only as long as a transaction, run
+// SELECT pg_advisory_xact_lock($1) in that transaction instead; the server
+// releases it at commit or rollback and it needs no unlock.
+//
+//	unlock, err := db.AcquireAdvisoryLock(ctx, reportLockKey)
+//	if err != nil { ... }
+//	defer unlock()
+func (db *DB) AcquireAdvisoryLock(ctx context.Context, key int64) (unlock func() error, err error) {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+        discardConn(conn)
+        return nil, err
+    }
+    return advisoryUnlock(conn, key), nil
+}
+
+// TryAdvisoryLock is AcquireAdvisoryLock without waiting: it takes the lock
+// with pg_try_advisory_lock and reports false, with a nil unlock, when another
+// session holds it.
+func (db *DB) TryAdvisoryLock(ctx context.Context, key int64) (unlock func() error, ok bool, err error) {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return nil, false, err
+    }
+    if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+        discardConn(conn)
+        return nil, false, err
+    }
+    if !ok {
+        return nil, false, conn.Close()
+    }
+    return advisoryUnlock(conn, key), true, nil
+}
+
+// advisoryUnlock returns the unlock function of the lock key held by conn.
+func advisoryUnlock(conn *sql.Conn, key int64) func() error {
+    var once sync.Once
+    var err error
+    return func() error {
+        once.Do(func() {
+            var released bool
+            if err = conn.QueryRowContext(context.Background(), "SELECT pg_advisory_unlock($1)", key).Scan(&released); err != nil {
+                discardConn(conn)
+                return
+            }
+            err = conn.Close()
+        })
+        return err
+    }
+}
+
+// discardConn closes conn and its connection instead of returning it to the
+// pool, for connections that may still hold a lock.
+func discardConn(conn *sql.Conn) {
+    conn.Raw(func(any) error { return driver.ErrBadConn })
+    conn.Close()
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+    "context"
+    "testing"
+    "time"
+)
+
+func TestAdvisoryLockUncontended(t *testing.T) {
+    db := Wrap(testDB(t))
+    ctx := context.Background()
+    unlock, err := db.AcquireAdvisoryLock(ctx, 4601)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := unlock(); err != nil {
+        t.Fatal(err)
+    }
+    if err := unlock(); err != nil {
+        t.Errorf("second unlock: %v", err)
+    }
+    unlock, ok, err := db.TryAdvisoryLock(ctx, 4601)
+    if err != nil || !ok {
+        t.Fatalf("released lock not free: %v, %v", ok, err)
+    }
+    if err := unlock(); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestAdvisoryLockContended(t *testing.T) {
+    db := Wrap(testDB(t))
+    ctx := context.Background()
+    unlock, err := db.AcquireAdvisoryLock(ctx, 4602)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, ok, err := db.TryAdvisoryLock(ctx, 4602); err != nil || ok {
+        t.Fatalf("held lock taken twice: %v, %v", ok, err)
+    }
+
+    short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+    defer cancel()
+    if _, err := db.AcquireAdvisoryLock(short, 4602); err == nil {
+        t.Fatal("held lock granted to a second session")
+    }
+
+    acquired := make(chan func() error)
+    go func() {
+        unlock, err := db.AcquireAdvisoryLock(ctx, 4602)
+        if err != nil {
+            t.Error(err)
+            close(acquired)
+            return
+        }
+        acquired <- unlock
+    }()
+    select {
+    case <-acquired:
+        t.Fatal("lock granted while held")
+    case <-time.After(100 * time.Millisecond):
+    }
+    if err := unlock(); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case next := <-acquired:
+        if next != nil {
+            next()
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("waiter not granted the released lock")
+    }
+}
+
+func TestAdvisoryLockReleasedWithConnection(t *testing.T) {
+    db := Wrap(testDB(t))
+    ctx := context.Background()
+    unlock, err := db.AcquireAdvisoryLock(ctx, 4603)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := db.ExecContext(ctx, "SELECT pg_terminate_backend(pid) FROM pg_locks WHERE locktype = 'advisory' AND objid = 4603"); err != nil {
+        t.Fatal(err)
+    }
+    if err := unlock(); err == nil {
+        t.Error("unlock on a dropped connection reported success")
+    }
+    next, ok, err := db.TryAdvisoryLock(ctx, 4603)
+    if err != nil || !ok {
+        t.Fatalf("lock of a dropped connection still held: %v, %v", ok, err)
+    }
+    next()
+}
@@ -0,0 +1,107 @@
+package driver
+
+import (
+    "database/sql"
+    "fmt"
+    "reflect"
+    "sync"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// Array returns a sql.Scanner that decodes an array column into dest, a
+// pointer to a slice such as *[]string for uuid[], *[]netip.Prefix for inet[]
+// or *[]json.RawMessage for jsonb[]. Array columns are returned as their text
+// array literal, such as {1,2,3}, which Array parses with the codec pgx
+// registers for dest's Go type; slices of other types, including string
+// kinds such as a Go enum type, are parsed as text[]. A NULL array leaves
+// dest nil.
+//
+//	rows.Scan(&id, driver.Array(&tags))
+func Array(dest any) sql.Scanner {
+    return arrayScanner{dest: dest}
+}
+
+type arrayScanner struct {
+    dest any
+}
+
+func (a arrayScanner) Scan(src any) error {
+    switch v := src.(type) {
+    case nil:
+        rv := reflect.ValueOf(a.dest)
+        if rv.Kind() != reflect.Pointer || rv.IsNil() {
+            return fmt.Errorf("serin: Array destination must be a non-nil pointer, got %T", a.dest)
+        }
+        rv.Elem().SetZero()
+        return nil
+    case string:
+        return scanArrayText([]byte(v), a.dest)
+    case []byte:
+        return scanArrayText(v, a.dest)
+    }
+    return fmt.Errorf("serin: cannot scan %T into an array", src)
+}
+
+// typeMaps holds type maps for parsing and formatting values away from a
+// connection, such as array literals; a pgtype.Map is not safe for
+// concurrent use.
+var typeMaps = sync.Pool{New: func() any {
+    m := pgtype.NewMap()
+    registerSystemTypes(m)
+    return m
+}}
+
+func scanArrayText(text []byte, dest any) error {
+    m := typeMaps.Get().(*pgtype.Map)
+    defer typeMaps.Put(m)
+    oid := uint32(pgtype.TextArrayOID)
+    if t, ok := m.TypeForValue(dest); ok {
+        if _, isArray := t.Codec.(*pgtype.ArrayCodec); isArray {
+            oid = t.OID
+        }
+    }
+    if err := m.Scan(oid, pgtype.TextFormatCode, text, dest); err != nil {
+        return fmt.Errorf("serin: scanning array into %T: %w", dest, err)
+    }
+    return nil
+}
+
+// arrayText returns raw, an array column in format, as its text array literal.
+func arrayText(m *pgtype.Map, oid uint32, format int16, raw []byte) (string, error) {
+    if format == pgtype.TextFormatCode {
+        return string(raw), nil
+    }
+    t, ok := m.TypeForOID(oid)
+    if !ok {
+        return "", fmt.Errorf("serin: unknown array type OID %d", oid)
+    }
+    decoded, err := t.Codec.DecodeValue(m, oid, format, raw)
+    if err != nil {
+        return "", err
+    }
+    text, err := m.Encode(oid, pgtype.TextFormatCode, decoded, nil)
+    return string(text), err
+}
+
+// arrayColumns reports which result columns hold arrays according to m.
+func arrayColumns(m *pgtype.Map, oids []uint32) []bool {
+    arrays := make([]bool, len(oids))
+    for i, oid := range oids {
+        if t, ok := m.TypeForOID(oid); ok {
+            _, arrays[i] = t.Codec.(*pgtype.ArrayCodec)
+        }
+    }
+    return arrays
+}
+
+// isArrayArg reports whether v is a slice or array argument that pgx should
+// encode itself instead of database/sql rejecting it.
+func isArrayArg(v any) bool {
+    switch v.(type) {
+    case nil, []byte:
+        return false
+    }
+    k := reflect.TypeOf(v).Kind()
+    return k == reflect.Slice || k == reflect.Array
+}
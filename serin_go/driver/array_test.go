@@ -0,0 +1,103 @@
+package driver
+
+import (
+    "encoding/json"
+    "net/netip"
+    "reflect"
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestArrayText(t *testing.T) {
+    m := pgtype.NewMap()
+    raw, err := m.Encode(pgtype.UUIDArrayOID, pgtype.BinaryFormatCode, [][16]byte{{0xa0, 0xee}, {0xc9, 0xbf}}, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    text, err := arrayText(m, pgtype.UUIDArrayOID, pgtype.BinaryFormatCode, raw)
+    want := "{a0ee0000-0000-0000-0000-000000000000,c9bf0000-0000-0000-0000-000000000000}"
+    if err != nil || text != want {
+        t.Errorf("got %q, %v, want %q", text, err, want)
+    }
+    if text, err := arrayText(m, pgtype.Int4ArrayOID, pgtype.TextFormatCode, []byte("{1,NULL}")); err != nil || text != "{1,NULL}" {
+        t.Errorf("text format gave %q, %v", text, err)
+    }
+}
+
+func TestArrayScan(t *testing.T) {
+    var ids []string
+    if err := Array(&ids).Scan("{a0ee0000-0000-0000-0000-000000000000,c9bf0000-0000-0000-0000-000000000000}"); err != nil {
+        t.Fatal(err)
+    }
+    if want := []string{"a0ee0000-0000-0000-0000-000000000000", "c9bf0000-0000-0000-0000-000000000000"}; !reflect.DeepEqual(ids, want) {
+        t.Errorf("got %v, want %v", ids, want)
+    }
+    var ns []int32
+    if err := Array(&ns).Scan([]byte("{1,2,3}")); err != nil || !reflect.DeepEqual(ns, []int32{1, 2, 3}) {
+        t.Errorf("int4[] gave %v, %v", ns, err)
+    }
+    var nets []netip.Prefix
+    if err := Array(&nets).Scan("{10.0.0.0/8,192.168.1.1/32}"); err != nil || len(nets) != 2 || nets[0].String() != "10.0.0.0/8" {
+        t.Errorf("inet[] gave %v, %v", nets, err)
+    }
+    var docs []json.RawMessage
+    if err := Array(&docs).Scan(`{"{\"a\": 1}","[]"}`); err != nil || len(docs) != 2 || string(docs[0]) != `{"a": 1}` {
+        t.Errorf("jsonb[] gave %q, %v", docs, err)
+    }
+    type color string
+    var colors []color
+    if err := Array(&colors).Scan("{red,green}"); err != nil || !reflect.DeepEqual(colors, []color{"red", "green"}) {
+        t.Errorf("enum[] gave %v, %v", colors, err)
+    }
+
+    if err := Array(&ids).Scan(nil); err != nil || ids != nil {
+        t.Errorf("NULL array gave %v, %v", ids, err)
+    }
+    if err := Array(&ids).Scan(int64(1)); err == nil {
+        t.Error("scanning an integer succeeded")
+    }
+}
+
+func TestArrayColumns(t *testing.T) {
+    got := arrayColumns(pgtype.NewMap(), []uint32{pgtype.Int8OID, pgtype.UUIDArrayOID, pgtype.JSONBArrayOID, 999999})
+    if want := []bool{false, true, true, false}; !reflect.DeepEqual(got, want) {
+        t.Errorf("got %v, want %v", got, want)
+    }
+}
+
+func TestArrayRoundTrip(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_arrays", "CREATE TABLE serin_arrays (ids uuid[], docs jsonb[], nets inet[])")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_arrays") })
+
+    ids := []string{"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "c9bf9e57-1685-4c89-bafb-ff5af830be8a"}
+    docs := []map[string]any{{"a": 1.0}, {"b": []any{"x", "y"}}}
+    if _, err := db.Exec("INSERT INTO serin_arrays VALUES ($1, $2, $3)", ids, docs, []string{"10.0.0.1/32", "::1/128"}); err != nil {
+        t.Fatal(err)
+    }
+
+    var gotIDs []string
+    var gotDocs []json.RawMessage
+    var gotNets []netip.Prefix
+    if err := db.QueryRow("SELECT ids, docs, nets FROM serin_arrays").Scan(Array(&gotIDs), Array(&gotDocs), Array(&gotNets)); err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(gotIDs, ids) {
+        t.Errorf("uuid[]: got %v, want %v", gotIDs, ids)
+    }
+    var decoded []map[string]any
+    for _, d := range gotDocs {
+        var v map[string]any
+        if err := json.Unmarshal(d, &v); err != nil {
+            t.Fatal(err)
+        }
+        decoded = append(decoded, v)
+    }
+    if !reflect.DeepEqual(decoded, docs) {
+        t.Errorf("jsonb[]: got %v, want %v", decoded, docs)
+    }
+    if len(gotNets) != 2 || gotNets[1] != netip.MustParsePrefix("::1/128") {
+        t.Errorf("inet[]: got %v", gotNets)
+    }
+}
@@ -0,0 +1,144 @@
+package driver
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// ErrAuthMethodNotAllowed is matched by errors.Is when the server asked for an
+// authentication method excluded by auth_methods / WithAuthMethods. The
+// password is never sent in that case.
+var ErrAuthMethodNotAllowed = errors.New("serin: authentication method not allowed")
+
+// AuthError reports a connection failure after the server chose an
+// authentication method.
+type AuthError struct {
+    Method string // password, md5, scram-sha-256, gss, sspi or none
+    Err    error
+}
+
+func (e *AuthError) Error() string {
+    if errors.Is(e.Err, ErrAuthMethodNotAllowed) {
+        return fmt.Sprintf("serin: server requested %s authentication, which is not in auth_methods", e.Method)
+    }
+    return fmt.Sprintf("serin: %s authentication failed: %v", e.Method, e.Err)
+}
+
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// authMethods are the names accepted by auth_methods, as used by libpq's
+// require_auth.
+var authMethods = map[string]bool{"password": true, "md5": true, "scram-sha-256": true, "gss": true, "sspi": true, "none": true}
+
+// parseAuthMethods parses a comma separated auth_methods value.
+func parseAuthMethods(s string) (map[string]bool, error) {
+    allowed := make(map[string]bool)
+    for _, m := range strings.Split(s, ",") {
+        m = strings.ToLower(strings.TrimSpace(m))
+        if !authMethods[m] {
+            return nil, fmt.Errorf("serin: unknown auth_methods entry %q", m)
+        }
+        allowed[m] = true
+    }
+    return allowed, nil
+}
+
+// authTap reads the backend message stream during startup to learn which
+// authentication method the server requests, refusing the ones not allowed
+// before pgconn acts on them. Once authentication completes it only forwards.
+type authTap struct {
+    r       io.Reader
+    allowed map[string]bool // nil allows every method
+    method  string          // method requested by the server, if any yet
+
+    hdr  [9]byte // type, length and, for 'R' messages, the auth code
+    nhdr int
+    skip int // body bytes left in the current message
+    done bool
+}
+
+func (t *authTap) Read(p []byte) (int, error) {
+    n, err := t.r.Read(p)
+    if !t.done {
+        if aerr := t.scan(p[:n]); aerr != nil {
+            return 0, aerr
+        }
+    }
+    return n, err
+}
+
+func (t *authTap) scan(b []byte) error {
+    for len(b) > 0 && !t.done {
+        if t.skip > 0 {
+            k := min(t.skip, len(b))
+            t.skip -= k
+            b = b[k:]
+            continue
+        }
+        need := 5
+        if t.nhdr > 0 && t.hdr[0] == 'R' {
+            need = 9
+        }
+        k := min(need-t.nhdr, len(b))
+        copy(t.hdr[t.nhdr:], b[:k])
+        t.nhdr += k
+        b = b[k:]
+        if t.nhdr < need {
+            continue
+        }
+        if need == 5 && t.hdr[0] == 'R' {
+            continue // read the auth code next
+        }
+        t.skip = int(binary.BigEndian.Uint32(t.hdr[1:5])) - (need - 1)
+        t.nhdr = 0
+        if t.hdr[0] != 'R' {
+            continue
+        }
+        code := binary.BigEndian.Uint32(t.hdr[5:9])
+        if code == 0 {
+            t.done = true
+            if t.method == "" {
+                t.method = "none"
+                return t.check()
+            }
+            return nil
+        }
+        if m := authCodeName(code); m != "" {
+            t.method = m
+            if err := t.check(); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+func (t *authTap) check() error {
+    if t.allowed != nil && !t.allowed[t.method] {
+        return &AuthError{Method: t.method, Err: ErrAuthMethodNotAllowed}
+    }
+    return nil
+}
+
+// authCodeName names the method of an AuthenticationRequest code, or returns
+// "" for continuation messages of a method already under way.
+func authCodeName(code uint32) string {
+    switch code {
+    case 3:
+        return "password"
+    case 5:
+        return "md5"
+    case 7:
+        return "gss"
+    case 9:
+        return "sspi"
+    case 10:
+        return "scram-sha-256"
+    case 2:
+        return "kerberos-v5"
+    }
+    return ""
+}
@@ -0,0 +1,111 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "net"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgproto3"
+)
+
+// md5Server asks for an MD5 password and rejects whatever it receives. It
+// reports on sent whether the client sent a password at all.
+func md5Server(t *testing.T, sent chan<- bool) (host, port string) {
+    return fakeServer(t, func(c net.Conn) {
+        be := pgproto3.NewBackend(c, c)
+        if _, err := be.ReceiveStartupMessage(); err != nil {
+            return
+        }
+        be.Send(&pgproto3.AuthenticationMD5Password{Salt: [4]byte{1, 2, 3, 4}})
+        be.Flush()
+        msg, err := be.Receive()
+        _, isPassword := msg.(*pgproto3.PasswordMessage)
+        sent <- err == nil && isPassword
+        be.Send(&pgproto3.ErrorResponse{Severity: "FATAL", Code: "28P01", Message: `password authentication failed for user "alice"`})
+        be.Flush()
+    })
+}
+
+func connectWith(t *testing.T, dsn string, opts ...Option) error {
+    t.Helper()
+    c, err := NewConnector(dsn, opts...)
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    conn, err := c.Connect(ctx)
+    if err == nil {
+        conn.Close()
+    }
+    return err
+}
+
+func TestAuthMethodInFailure(t *testing.T) {
+    sent := make(chan bool, 1)
+    host, port := md5Server(t, sent)
+    err := connectWith(t, "host="+host+" port="+port+" user=alice password=secret sslmode=disable")
+    var authErr *AuthError
+    if !errors.As(err, &authErr) || authErr.Method != "md5" {
+        t.Fatalf("got %v, want an md5 AuthError", err)
+    }
+    if !strings.Contains(err.Error(), "md5 authentication failed") || !strings.Contains(err.Error(), "28P01") {
+        t.Errorf("error does not name the method and cause: %v", err)
+    }
+    if !<-sent {
+        t.Error("password was not sent for an allowed method")
+    }
+}
+
+func TestAuthMethodsRejectWeaker(t *testing.T) {
+    for _, tc := range []struct {
+        dsn  string
+        opts []Option
+    }{
+        {dsn: " auth_methods=scram-sha-256"},
+        {opts: []Option{WithAuthMethods("scram-sha-256", "gss")}},
+    } {
+        sent := make(chan bool, 1)
+        host, port := md5Server(t, sent)
+        err := connectWith(t, "host="+host+" port="+port+" user=alice password=secret sslmode=disable"+tc.dsn, tc.opts...)
+        if !errors.Is(err, ErrAuthMethodNotAllowed) || !strings.Contains(err.Error(), "md5") {
+            t.Fatalf("got %v, want ErrAuthMethodNotAllowed naming md5", err)
+        }
+        select {
+        case ok := <-sent:
+            if ok {
+                t.Error("password was sent for a rejected method")
+            }
+        case <-time.After(time.Second):
+        }
+    }
+}
+
+func TestAuthTapSplitReads(t *testing.T) {
+    stream := []byte("R\x00\x00\x00\x0c\x00\x00\x00\x05salt")
+    tap := &authTap{}
+    for _, b := range stream {
+        if err := tap.scan([]byte{b}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if tap.method != "md5" || tap.done {
+        t.Errorf("method %q done %v after byte-at-a-time scan", tap.method, tap.done)
+    }
+    if err := tap.scan([]byte("R\x00\x00\x00\x08\x00\x00\x00\x00Z")); err != nil || !tap.done {
+        t.Errorf("AuthenticationOk not recognised: %v", err)
+    }
+}
+
+func TestParseAuthMethods(t *testing.T) {
+    m, err := parseAuthMethods("SCRAM-SHA-256, md5")
+    if err != nil || !m["scram-sha-256"] || !m["md5"] || len(m) != 2 {
+        t.Errorf("got %v, %v", m, err)
+    }
+    if _, err := parseAuthMethods("scram,md5"); err == nil {
+        t.Error("unknown method accepted")
+    }
+}
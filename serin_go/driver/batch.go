@@ -0,0 +1,109 @@
+package driver
+
+import (
+    "context"
+    "database/sql/driver"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// maxParams is the number of bind parameters the protocol allows in one
+// statement.
+const maxParams = 65535
+
+// BatchInsert inserts rows into table with multi-row INSERT statements of at
+// most chunkSize rows each, all in one transaction, and returns the number of
+// rows inserted. Chunks are shrunk as needed to stay within the protocol's
+// limit of 65535 parameters; chunkSize <= 0 uses the largest chunk that fits.
+// Names are quoted as for CopyFromCSV. Nothing is inserted if any chunk
+// fails. For hundreds of thousands of rows CopyFrom is faster.
+func (db *DB) BatchInsert(ctx context.Context, table string, columns []string, rows [][]any, chunkSize int) (int64, error) {
+    if len(columns) == 0 || len(columns) > maxParams {
+        return 0, fmt.Errorf("serin: BatchInsert into %s needs 1 to %d columns, got %d", table, maxParams, len(columns))
+    }
+    for i, row := range rows {
+        if len(row) != len(columns) {
+            return 0, fmt.Errorf("serin: BatchInsert row %d has %d values for %d columns", i, len(row), len(columns))
+        }
+    }
+    name, err := tableIdent(table)
+    if err != nil {
+        return 0, err
+    }
+    cols, err := columnIdents(columns)
+    if err != nil {
+        return 0, err
+    }
+    if len(rows) == 0 {
+        return 0, nil
+    }
+    chunk := batchChunkSize(chunkSize, len(columns))
+    var n int64
+    err = withConn(ctx, db.DB, func(c *serinConn) error {
+        // BeginTx, not c.conn.Begin, so the metadata and notice handler of
+        // ctx apply to the transaction.
+        tx, err := c.BeginTx(ctx, driver.TxOptions{})
+        if err != nil {
+            return err
+        }
+        defer tx.Rollback()
+        args := make([]any, 0, chunk*len(columns))
+        for start := 0; start < len(rows); start += chunk {
+            part := rows[start:min(start+chunk, len(rows))]
+            args = args[:0]
+            for _, row := range part {
+                args = append(args, row...)
+            }
+            res, err := c.exec(ctx, insertSQL(name.Sanitize(), cols, len(part)), args)
+            if err != nil {
+                return err
+            }
+            affected, _ := res.RowsAffected()
+            n += affected
+        }
+        return tx.Commit()
+    })
+    if err != nil {
+        return 0, err
+    }
+    return n, nil
+}
+
+// batchChunkSize caps chunkSize so a chunk of rows with columns values each
+// stays within maxParams.
+func batchChunkSize(chunkSize, columns int) int {
+    limit := maxParams / columns
+    if chunkSize <= 0 || chunkSize > limit {
+        return limit
+    }
+    return chunkSize
+}
+
+// insertSQL builds INSERT INTO table (cols) VALUES ($1, ...), ... for rows
+// rows.
+func insertSQL(table string, cols []string, rows int) string {
+    var b strings.Builder
+    b.WriteString("INSERT INTO ")
+    b.WriteString(table)
+    b.WriteString(" (")
+    b.WriteString(strings.Join(cols, ", "))
+    b.WriteString(") VALUES ")
+    p := 1
+    for r := 0; r < rows; r++ {
+        if r > 0 {
+            b.WriteString(", ")
+        }
+        b.WriteByte('(')
+        for i := range cols {
+            if i > 0 {
+                b.WriteString(", ")
+            }
+            b.WriteByte('$')
+            b.WriteString(strconv.Itoa(p))
+            p++
+        }
+        b.WriteByte(')')
+    }
+    return b.String()
+}
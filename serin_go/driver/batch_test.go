@@ -0,0 +1,78 @@
+package driver
+
+import (
+    "context"
+    "testing"
+)
+
+func TestBatchChunkSize(t *testing.T) {
+    for _, tc := range []struct{ chunk, cols, want int }{
+        {100, 3, 100},
+        {0, 3, 21845},
+        {-1, 1, 65535},
+        {50000, 2, 32767},
+        {32767, 2, 32767},
+    } {
+        if got := batchChunkSize(tc.chunk, tc.cols); got != tc.want {
+            t.Errorf("batchChunkSize(%d, %d) = %d, want %d", tc.chunk, tc.cols, got, tc.want)
+        }
+    }
+}
+
+func TestInsertSQL(t *testing.T) {
+    got := insertSQL(`"t"`, []string{`"a"`, `"b"`}, 2)
+    if want := `INSERT INTO "t" ("a", "b") VALUES ($1, $2), ($3, $4)`; got != want {
+        t.Errorf("got  %s\nwant %s", got, want)
+    }
+}
+
+func TestBatchInsert(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_batch", "CREATE TABLE serin_batch (id int8 PRIMARY KEY, a int8, b int8)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_batch") })
+    ctx := context.Background()
+    rowsOf := func(from, n int) [][]any {
+        rows := make([][]any, n)
+        for i := range rows {
+            rows[i] = []any{int64(from + i), int64(i), int64(-i)}
+        }
+        return rows
+    }
+    count := func() int {
+        var n int
+        if err := db.QueryRow("SELECT count(*) FROM serin_batch").Scan(&n); err != nil {
+            t.Fatal(err)
+        }
+        return n
+    }
+
+    // Exactly one chunk, one row past a chunk, and the parameter limit
+    // forcing 21845-row chunks for 3 columns.
+    total := 0
+    for _, tc := range []struct{ rows, chunk int }{{100, 100}, {101, 100}, {30000, 0}, {30000, 40000}} {
+        n, err := Wrap(db).BatchInsert(ctx, "serin_batch", []string{"id", "a", "b"}, rowsOf(total, tc.rows), tc.chunk)
+        if err != nil || n != int64(tc.rows) {
+            t.Fatalf("%d rows in chunks of %d: got %d, %v", tc.rows, tc.chunk, n, err)
+        }
+        total += tc.rows
+    }
+    if got := count(); got != total {
+        t.Fatalf("table has %d rows, want %d", got, total)
+    }
+
+    // A failing chunk rolls back the chunks before it.
+    bad := append(rowsOf(total, 10), []any{int64(0), int64(0), int64(0)})
+    if _, err := Wrap(db).BatchInsert(ctx, "serin_batch", []string{"id", "a", "b"}, bad, 5); err == nil {
+        t.Fatal("duplicate key accepted")
+    }
+    if got := count(); got != total {
+        t.Errorf("failed batch left %d rows, want %d", got, total)
+    }
+
+    if _, err := Wrap(db).BatchInsert(ctx, "serin_batch", []string{"id", "a"}, rowsOf(0, 1), 10); err == nil {
+        t.Error("row with too many values accepted")
+    }
+    if _, err := Wrap(db).BatchInsert(ctx, "serin_batch", make([]string, maxParams+1), nil, 10); err == nil {
+        t.Error("more columns than parameters accepted")
+    }
+}
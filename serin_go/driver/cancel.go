@@ -0,0 +1,40 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "sync"
+)
+
+// CancelAll asks the server to cancel whatever the connections of c are
+// running, for emergency load shedding. Only connections in the middle of a
+// statement, a row set that is still open or a COPY are sent a request; idle
+// connections, in the pool or checked out, are left alone. Cancellation is
+// best-effort: each request travels on a separate connection and races with
+// the statement it targets, so a statement that is just finishing may
+// complete, and one that starts on the connection right after it may be
+// cancelled instead. Cancelled statements fail with SQLSTATE 57014.
+// CancelAll returns once every request has been sent, joining the errors of
+// those that could not be.
+func (c *Connector) CancelAll(ctx context.Context) error {
+    c.mu.Lock()
+    conns := make([]*serinConn, 0, len(c.conns))
+    for sc := range c.conns {
+        if sc.running.Load() > 0 {
+            conns = append(conns, sc)
+        }
+    }
+    c.mu.Unlock()
+
+    errs := make([]error, len(conns))
+    var wg sync.WaitGroup
+    for i, sc := range conns {
+        wg.Add(1)
+        go func(i int, sc *serinConn) {
+            defer wg.Done()
+            errs[i] = sc.conn.PgConn().CancelRequest(ctx)
+        }(i, sc)
+    }
+    wg.Wait()
+    return errors.Join(errs...)
+}
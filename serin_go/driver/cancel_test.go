@@ -0,0 +1,142 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "net"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgproto3"
+)
+
+// keyServer fakes a server that hands every connection a distinct backend
+// PID and reports the PIDs of the cancel requests it receives.
+func keyServer(t *testing.T, cancelled chan<- uint32) (host, port string) {
+    var pid atomic.Uint32
+    return fakeServer(t, func(c net.Conn) {
+        be := pgproto3.NewBackend(c, c)
+        msg, err := be.ReceiveStartupMessage()
+        if err != nil {
+            return
+        }
+        if cr, ok := msg.(*pgproto3.CancelRequest); ok {
+            if cr.SecretKey == cr.ProcessID*7 {
+                cancelled <- cr.ProcessID
+            }
+            return
+        }
+        id := pid.Add(1)
+        be.Send(&pgproto3.AuthenticationOk{})
+        be.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+        be.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+        be.Send(&pgproto3.BackendKeyData{ProcessID: id, SecretKey: id * 7})
+        be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+        be.Flush()
+        for {
+            msg, err := be.Receive()
+            if err != nil {
+                return
+            }
+            if _, ok := msg.(*pgproto3.Query); ok {
+                // Lookups made while connecting find nothing.
+                be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 0")})
+                be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+                be.Flush()
+            }
+        }
+    })
+}
+
+func TestCancelAllSkipsIdleConnections(t *testing.T) {
+    cancelled := make(chan uint32, 10)
+    host, port := keyServer(t, cancelled)
+    c, err := NewConnector("host=" + host + " port=" + port + " user=alice sslmode=disable")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    var conns []*serinConn
+    for i := 0; i < 3; i++ {
+        conn, err := c.Connect(ctx)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer conn.Close()
+        conns = append(conns, conn.(*serinConn))
+    }
+    // The first and last connections, PIDs 1 and 3, are running statements.
+    conns[0].running.Add(1)
+    conns[2].running.Add(1)
+    if err := c.CancelAll(ctx); err != nil {
+        t.Fatal(err)
+    }
+    got := map[uint32]bool{}
+    for len(got) < 2 {
+        select {
+        case pid := <-cancelled:
+            got[pid] = true
+        case <-ctx.Done():
+            t.Fatalf("cancel requests for %v only", got)
+        }
+    }
+    select {
+    case pid := <-cancelled:
+        got[pid] = true
+    case <-time.After(100 * time.Millisecond):
+    }
+    if len(got) != 2 || !got[1] || !got[3] {
+        t.Errorf("cancel requests for %v, want the busy PIDs 1 and 3 only", got)
+    }
+}
+
+func TestCancelAll(t *testing.T) {
+    db, c := testConnectorDB(t)
+    const n = 4
+    var wg sync.WaitGroup
+    errs := make(chan error, n)
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            _, err := db.Exec("SELECT pg_sleep(30)")
+            errs <- err
+        }()
+    }
+    // Wait for the sleeps to reach the server.
+    for deadline := time.Now().Add(10 * time.Second); ; {
+        var running int
+        if err := db.QueryRow("SELECT count(*) FROM pg_stat_activity WHERE query = 'SELECT pg_sleep(30)' AND state = 'active'").Scan(&running); err != nil {
+            t.Fatal(err)
+        }
+        if running == n {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("only %d queries started", running)
+        }
+        time.Sleep(20 * time.Millisecond)
+    }
+    start := time.Now()
+    if err := c.CancelAll(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    wg.Wait()
+    close(errs)
+    for err := range errs {
+        var pgErr *pgconn.PgError
+        if !errors.As(err, &pgErr) || pgErr.Code != "57014" {
+            t.Errorf("got %v, want a query_canceled error", err)
+        }
+    }
+    if elapsed := time.Since(start); elapsed > 10*time.Second {
+        t.Errorf("queries took %v to stop", elapsed)
+    }
+    if got := c.Metrics().CanceledErrors; got != n {
+        t.Errorf("recorded %d cancellations, want %d", got, n)
+    }
+}
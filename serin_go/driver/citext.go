@@ -0,0 +1,32 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// registerCitext registers the citext extension type on sc, when the
+// extension is installed, so citext columns scan into and bind from strings.
+// Extension types get their OID when the extension is created, so it is
+// looked up on every new connection, in a single simple protocol round trip.
+func (c *Connector) registerCitext(ctx context.Context, sc *serinConn) error {
+    var oid, array uint32
+    err := sc.conn.QueryRow(ctx, "SELECT oid, typarray FROM pg_type WHERE typname = 'citext' LIMIT 1", pgx.QueryExecModeSimpleProtocol).Scan(&oid, &array)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("serin: looking up citext: %w", err)
+    }
+    m := sc.conn.TypeMap()
+    citext := &pgtype.Type{Name: "citext", OID: oid, Codec: pgtype.TextCodec{}}
+    m.RegisterType(citext)
+    if array != 0 {
+        m.RegisterType(&pgtype.Type{Name: "_citext", OID: array, Codec: &pgtype.ArrayCodec{ElementType: citext}})
+    }
+    return nil
+}
@@ -0,0 +1,52 @@
+package driver
+
+import (
+    "database/sql"
+    "testing"
+)
+
+func TestCitext(t *testing.T) {
+    setup := testDB(t)
+    if _, err := setup.Exec("CREATE EXTENSION IF NOT EXISTS citext"); err != nil {
+        t.Skipf("citext unavailable: %v", err)
+    }
+    mustExec(t, setup, "DROP TABLE IF EXISTS serin_citext", "CREATE TABLE serin_citext (id int, name citext)")
+    t.Cleanup(func() { setup.Exec("DROP TABLE serin_citext") })
+    // Connections opened after CREATE EXTENSION see the type.
+    db, _ := testConnectorDB(t)
+
+    if _, err := db.Exec("INSERT INTO serin_citext VALUES (1, $1), (2, $2)", "Alice", nil); err != nil {
+        t.Fatal(err)
+    }
+    rows, err := db.Query("SELECT name FROM serin_citext ORDER BY id")
+    if err != nil {
+        t.Fatal(err)
+    }
+    types, _ := rows.ColumnTypes()
+    if name := types[0].DatabaseTypeName(); name != "CITEXT" {
+        t.Errorf("type name %s", name)
+    }
+    var got []sql.NullString
+    for rows.Next() {
+        var s sql.NullString
+        if err := rows.Scan(&s); err != nil {
+            t.Fatal(err)
+        }
+        got = append(got, s)
+    }
+    if err := rows.Err(); err != nil {
+        t.Fatal(err)
+    }
+    if len(got) != 2 || got[0] != (sql.NullString{String: "Alice", Valid: true}) || got[1].Valid {
+        t.Errorf("round tripped %v", got)
+    }
+
+    var id int
+    if err := db.QueryRow("SELECT id FROM serin_citext WHERE name = $1", "aLiCe").Scan(&id); err != nil || id != 1 {
+        t.Errorf("case-insensitive match: %d, %v", id, err)
+    }
+    var names []string
+    if err := db.QueryRow("SELECT array_agg(name) FROM serin_citext WHERE name IS NOT NULL").Scan(Array(&names)); err != nil || len(names) != 1 || names[0] != "Alice" {
+        t.Errorf("citext[]: %v, %v", names, err)
+    }
+}
@@ -0,0 +1,54 @@
+package driver
+
+import (
+    "strings"
+    "unicode"
+)
+
+// leadingKeywords returns up to n upper-cased keywords at the start of query,
+// skipping whitespace and SQL comments.
+func leadingKeywords(query string, n int) []string {
+    var words []string
+    s := query
+    for len(words) < n {
+        s = skipSpaceAndComments(s)
+        end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && r != '_' })
+        if end == -1 {
+            end = len(s)
+        }
+        if end == 0 {
+            break
+        }
+        words = append(words, strings.ToUpper(s[:end]))
+        s = s[end:]
+    }
+    return words
+}
+
+// skipSpaceAndComments returns s without its leading whitespace and comments.
+func skipSpaceAndComments(s string) string {
+    for s != "" {
+        kind, n := nextToken(s)
+        if kind != tokenSpace && kind != tokenLineComment && kind != tokenBlockComment {
+            break
+        }
+        s = s[n:]
+    }
+    return s
+}
+
+// isTxControl reports whether query starts or ends a transaction on its own.
+// Savepoint commands and two-phase COMMIT/ROLLBACK PREPARED are not included.
+func isTxControl(query string) bool {
+    kw := leadingKeywords(query, 2)
+    if len(kw) == 0 {
+        return false
+    }
+    switch kw[0] {
+    case "BEGIN", "START", "END", "ABORT":
+        return true
+    case "COMMIT", "ROLLBACK":
+        return len(kw) < 2 || (kw[1] != "PREPARED" && kw[1] != "TO")
+    }
+    return false
+}
@@ -0,0 +1,299 @@
+package driver
+
+import (
+    "bufio"
+    "context"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgproto3"
+)
+
+// Connector opens SerinDB connections with driver level settings applied on
+// top of the DSN. Use it with sql.OpenDB:
+//
+//	c, err := driver.NewConnector(dsn, driver.WithStatementCacheCapacity(128))
+//	db := sql.OpenDB(c)
+type Connector struct {
+    config        *pgx.ConnConfig
+    cacheCapacity int
+    role          string
+    drainTimeout  time.Duration
+    authMethods   map[string]bool // nil allows every method
+    bufferRows    int
+    discardAll    bool
+    moneyMode     MoneyMode
+    metaPrefix    string
+    resultLimit   resultLimit
+    strictTime    bool
+    timeLocation  *time.Location
+    rejectWrites  bool
+    emptyAsNull   bool
+    socket        string
+    enums         map[string]map[string]bool // RegisterEnum types and their values
+    deadlockHook  func(ctx context.Context, query string, err *pgconn.PgError)
+    commenter     func(ctx context.Context) map[string]string
+    slowQuery     slowQueryLog
+    logger        *slog.Logger
+    metrics       Metrics
+
+    mu      sync.Mutex
+    conns   map[*serinConn]struct{} // open connections
+    drained chan struct{}           // closed when conns empties during Close
+}
+
+// ErrDrainTimeout is returned by Connector.Close when connections were still in
+// use at the end of the drain window and had to be closed forcibly.
+var ErrDrainTimeout = errors.New("serin: drain timeout expired with connections still in use")
+
+// Option configures a Connector.
+type Option func(*Connector)
+
+// WithStatementCacheCapacity bounds the per-connection prepared statement cache,
+// overriding the statement_cache_capacity DSN parameter. Zero disables caching,
+// and with it the per-parameter format selection and domain resolution that
+// rely on statements being described ahead of time.
+func WithStatementCacheCapacity(n int) Option {
+    return func(c *Connector) { c.cacheCapacity = n }
+}
+
+// WithRole makes every connection SET ROLE to role after logging in, so the
+// login role only needs to be granted membership of it. The role is applied
+// again whenever a connection is returned to the pool.
+func WithRole(role string) Option {
+    return func(c *Connector) { c.role = role }
+}
+
+// WithDrainTimeout makes db.Close wait up to d for connections that are still
+// running queries to be returned to the pool before their sockets are closed
+// underneath them. Without it Close returns immediately and busy connections
+// are closed as they are released.
+func WithDrainTimeout(d time.Duration) Option {
+    return func(c *Connector) { c.drainTimeout = d }
+}
+
+// WithAuthMethods restricts the authentication methods the server may ask
+// for, overriding the auth_methods DSN parameter. Accepted names are
+// password, md5, scram-sha-256, gss, sspi and none (trust). The connection is
+// refused with ErrAuthMethodNotAllowed before any credential is sent.
+func WithAuthMethods(methods ...string) Option {
+    return func(c *Connector) {
+        c.authMethods = make(map[string]bool)
+        for _, m := range methods {
+            c.authMethods[strings.ToLower(m)] = true
+        }
+    }
+}
+
+// WithResultBufferRows makes result sets prefetch up to n rows at a time,
+// overriding the result_buffer_rows DSN parameter. Zero streams rows one by
+// one.
+func WithResultBufferRows(n int) Option {
+    return func(c *Connector) { c.bufferRows = n }
+}
+
+// WithDiscardAll makes connections run DISCARD ALL before each reuse,
+// dropping every trace of the previous borrower's session (settings,
+// temporary tables, prepared statements, advisory locks), like the
+// discard_all DSN parameter.
+func WithDiscardAll() Option {
+    return func(c *Connector) { c.discardAll = true }
+}
+
+// NewConnector parses dsn and applies opts. Besides the pgx connection
+// parameters the DSN may carry the driver parameters documented on the
+// corresponding options, such as auth_methods.
+func NewConnector(dsn string, opts ...Option) (*Connector, error) {
+    cfg, err := pgx.ParseConfig(dsn)
+    if err != nil {
+        return nil, err
+    }
+    cfg.DialFunc = sniffDial(cfg.DialFunc)
+    c := &Connector{config: cfg, conns: make(map[*serinConn]struct{})}
+    if v, ok := takeParam(cfg, "auth_methods"); ok {
+        if c.authMethods, err = parseAuthMethods(v); err != nil {
+            return nil, err
+        }
+    }
+    if v, ok := takeParam(cfg, "result_buffer_rows"); ok {
+        if c.bufferRows, err = strconv.Atoi(v); err != nil || c.bufferRows < 0 {
+            return nil, fmt.Errorf("serin: invalid result_buffer_rows %q", v)
+        }
+    }
+    if v, ok := takeParam(cfg, "discard_all"); ok {
+        if c.discardAll, err = strconv.ParseBool(v); err != nil {
+            return nil, fmt.Errorf("serin: invalid discard_all %q", v)
+        }
+    }
+    if v, ok := takeParam(cfg, "money_mode"); ok {
+        if c.moneyMode, err = parseMoneyMode(v); err != nil {
+            return nil, err
+        }
+    }
+    if cfg.DefaultQueryExecMode == pgx.QueryExecModeCacheStatement {
+        // The driver keeps its own statement cache so it can count and bound it.
+        c.cacheCapacity = cfg.StatementCacheCapacity
+        cfg.StatementCacheCapacity = 0
+        cfg.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+    }
+    for _, opt := range opts {
+        opt(c)
+    }
+    if c.role != "" {
+        if _, err := Ident(c.role); err != nil {
+            return nil, err
+        }
+    }
+    if c.socket != "" {
+        if err := c.useUnixSocket(c.socket); err != nil {
+            return nil, err
+        }
+    }
+    return c, nil
+}
+
+// takeParam removes a driver parameter that pgx left in the runtime
+// parameters so it is not sent to the server as a setting.
+func takeParam(cfg *pgx.ConnConfig, key string) (string, bool) {
+    v, ok := cfg.RuntimeParams[key]
+    delete(cfg.RuntimeParams, key)
+    return v, ok
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+    cfg := c.config.Copy()
+    var sc *serinConn
+    cfg.OnNotice = func(_ *pgconn.PgConn, n *pgconn.Notice) {
+        if sc != nil {
+            sc.notice(n)
+        }
+    }
+    describe := &describeTracer{}
+    cfg.Tracer = describe
+    var tap *authTap
+    cfg.BuildFrontend = func(r io.Reader, w io.Writer) *pgproto3.Frontend {
+        if c.bufferRows > 0 {
+            r = bufio.NewReaderSize(r, prefetchReadSize)
+        }
+        tap = &authTap{r: r, allowed: c.authMethods}
+        return pgproto3.NewFrontend(tap, w)
+    }
+    conn, err := pgx.ConnectConfig(ctx, cfg)
+    if err != nil {
+        var unsupported *UnsupportedServerError
+        var authErr *AuthError
+        switch {
+        case errors.As(err, &unsupported):
+            return nil, unsupported
+        case errors.As(err, &authErr):
+            return nil, authErr
+        case tap != nil && tap.method != "" && !tap.done:
+            return nil, &AuthError{Method: tap.method, Err: err}
+        }
+        return nil, err
+    }
+    sc = &serinConn{conn: conn, connector: c, stats: ConnStats{Established: time.Now()}, describe: describe}
+    if c.cacheCapacity > 0 {
+        sc.stmts = newStmtCache(c.cacheCapacity)
+    }
+    if err := c.afterConnect(ctx, sc); err != nil {
+        conn.Close(ctx)
+        return nil, err
+    }
+    c.mu.Lock()
+    c.conns[sc] = struct{}{}
+    c.mu.Unlock()
+    return sc, nil
+}
+
+// release forgets a connection that is being closed.
+func (c *Connector) release(sc *serinConn) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    delete(c.conns, sc)
+    if c.drained != nil && len(c.conns) == 0 {
+        close(c.drained)
+        c.drained = nil
+    }
+}
+
+// Close is called by sql.DB.Close once idle connections are closed. With a
+// drain timeout it blocks until the busy connections are released, closing
+// the sockets of those still busy when the timeout expires.
+func (c *Connector) Close() error {
+    if c.drainTimeout <= 0 {
+        return nil
+    }
+    c.mu.Lock()
+    if len(c.conns) == 0 {
+        c.mu.Unlock()
+        return nil
+    }
+    drained := make(chan struct{})
+    c.drained = drained
+    c.mu.Unlock()
+
+    timer := time.NewTimer(c.drainTimeout)
+    defer timer.Stop()
+    select {
+    case <-drained:
+        return nil
+    case <-timer.C:
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if len(c.conns) == 0 {
+        return nil
+    }
+    for sc := range c.conns {
+        // The socket is safe to close while another goroutine uses the conn.
+        sc.conn.PgConn().Conn().Close()
+    }
+    return ErrDrainTimeout
+}
+
+// afterConnect prepares the session of a freshly opened connection.
+func (c *Connector) afterConnect(ctx context.Context, sc *serinConn) error {
+    registerSystemTypes(sc.conn.TypeMap())
+    if c.timeLocation != nil {
+        registerTimeLocation(sc.conn.TypeMap(), c.timeLocation)
+    }
+    if err := c.registerCitext(ctx, sc); err != nil {
+        return err
+    }
+    if err := c.registerEnums(ctx, sc); err != nil {
+        return err
+    }
+    if err := c.checkRecovery(ctx, sc); err != nil {
+        return err
+    }
+    if err := c.registerMoney(ctx, sc); err != nil {
+        return err
+    }
+    return c.applyRole(ctx, sc)
+}
+
+func (c *Connector) applyRole(ctx context.Context, sc *serinConn) error {
+    if c.role == "" {
+        return nil
+    }
+    if _, err := sc.conn.Exec(ctx, "SET ROLE "+pgx.Identifier{c.role}.Sanitize()); err != nil {
+        return fmt.Errorf("serin: SET ROLE %s failed: %w", c.role, err)
+    }
+    return nil
+}
+
+func (c *Connector) Driver() driver.Driver { return &serinDriver{} }
+
+// Metrics returns a snapshot of the counters collected by connections opened
+// through c.
+func (c *Connector) Metrics() MetricsSnapshot { return c.metrics.snapshot() }
@@ -0,0 +1,112 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestWithRole(t *testing.T) {
+    admin := testDB(t)
+    mustExec(t, admin,
+        `DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'Serin Runtime') THEN CREATE ROLE "Serin Runtime"; END IF; END $$`,
+        `GRANT "Serin Runtime" TO CURRENT_USER`,
+    )
+    db, _ := testConnectorDB(t, WithRole("Serin Runtime"))
+    db.SetMaxOpenConns(1)
+
+    var current, session string
+    if err := db.QueryRow("SELECT current_user, session_user").Scan(&current, &session); err != nil {
+        t.Fatal(err)
+    }
+    if current != "Serin Runtime" || session == current {
+        t.Fatalf("current_user %q, session_user %q", current, session)
+    }
+
+    // A borrower resetting the role must not leak into the next one.
+    conn, err := db.Conn(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := conn.ExecContext(context.Background(), "RESET ROLE"); err != nil {
+        t.Fatal(err)
+    }
+    conn.Close()
+    if err := db.QueryRow("SELECT current_user").Scan(&current); err != nil {
+        t.Fatal(err)
+    }
+    if current != "Serin Runtime" {
+        t.Errorf("role not restored on reuse, current_user %q", current)
+    }
+}
+
+func TestWithRoleRevokedBetweenBorrows(t *testing.T) {
+    admin := testDB(t)
+    mustExec(t, admin,
+        `DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'serin_revoked') THEN CREATE ROLE serin_revoked; END IF; END $$`,
+        `GRANT serin_revoked TO CURRENT_USER`,
+    )
+    t.Cleanup(func() { admin.Exec("DROP ROLE IF EXISTS serin_revoked") })
+    db, _ := testConnectorDB(t, WithRole("serin_revoked"))
+    db.SetMaxOpenConns(1)
+    var current string
+    if err := db.QueryRow("SELECT current_user").Scan(&current); err != nil || current != "serin_revoked" {
+        t.Fatalf("current_user %q, %v", current, err)
+    }
+
+    // Dropping the role makes SET ROLE fail even for a superuser.
+    mustExec(t, admin, "REVOKE serin_revoked FROM CURRENT_USER", "DROP ROLE serin_revoked")
+    var session string
+    if err := db.QueryRow("SELECT current_user, session_user").Scan(&current, &session); err == nil {
+        t.Errorf("query ran as %q (session_user %q) after the role was dropped", current, session)
+    }
+}
+
+func TestWithRoleFailure(t *testing.T) {
+    db, _ := testConnectorDB(t, WithRole("serin_no_such_role"))
+    if err := db.Ping(); err == nil || !strings.Contains(err.Error(), "SET ROLE serin_no_such_role") {
+        t.Fatalf("got %v, want a SET ROLE failure", err)
+    }
+}
+
+func TestDrainWaitsForInFlightQuery(t *testing.T) {
+    db, _ := testConnectorDB(t, WithDrainTimeout(5*time.Second))
+    if err := db.Ping(); err != nil {
+        t.Fatal(err)
+    }
+    done := make(chan error, 1)
+    go func() {
+        _, err := db.Exec("SELECT pg_sleep(0.5)")
+        done <- err
+    }()
+    time.Sleep(100 * time.Millisecond)
+    if err := db.Close(); err != nil {
+        t.Fatalf("Close: %v", err)
+    }
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Errorf("in-flight query failed during drain: %v", err)
+        }
+    case <-time.After(time.Second):
+        t.Error("in-flight query did not finish after Close drained")
+    }
+}
+
+func TestDrainTimeoutForcesClose(t *testing.T) {
+    db, _ := testConnectorDB(t, WithDrainTimeout(200*time.Millisecond))
+    done := make(chan error, 1)
+    go func() {
+        _, err := db.Exec("SELECT pg_sleep(10)")
+        done <- err
+    }()
+    time.Sleep(100 * time.Millisecond)
+    if err := db.Close(); !errors.Is(err, ErrDrainTimeout) {
+        t.Fatalf("Close: got %v, want ErrDrainTimeout", err)
+    }
+    if err := <-done; err == nil {
+        t.Error("query survived a forced close")
+    }
+}
@@ -0,0 +1,28 @@
+package driver
+
+import "time"
+
+// ConnStats describes the life of a single connection.
+type ConnStats struct {
+    Established time.Time // when the connection was opened
+    LastUsed    time.Time // when the last query started; zero if none yet
+    Queries     int64     // statements executed or queried so far
+}
+
+// StatsReporter is implemented by the connections of this driver. Reach it
+// through sql.Conn.Raw:
+//
+//	conn.Raw(func(dc any) error { st = dc.(driver.StatsReporter).Stats(); return nil })
+type StatsReporter interface {
+    Stats() ConnStats
+}
+
+func (c *serinConn) Stats() ConnStats {
+    return c.stats
+}
+
+// used records the start of a query on c.
+func (c *serinConn) used() {
+    c.stats.LastUsed = time.Now()
+    c.stats.Queries++
+}
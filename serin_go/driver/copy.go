@@ -0,0 +1,271 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidCopyOptions is wrapped by every CSVOptions validation failure.
+var ErrInvalidCopyOptions = errors.New("serin: invalid COPY options")
+
+// CSVOptions controls how CopyFromCSV asks the server to parse its input,
+// and how QueryToCSV writes its output.
+// Zero values fall back to the server defaults: no header, ',' delimiter,
+// '"' quote, unquoted empty string as NULL and the client encoding.
+type CSVOptions struct {
+    Header    bool
+    Delimiter rune
+    Quote     rune
+    Null      string
+    Encoding  string
+}
+
+func (o CSVOptions) validate() error {
+    for _, opt := range []struct {
+        name string
+        r    rune
+    }{{"delimiter", o.Delimiter}, {"quote", o.Quote}} {
+        if opt.r == 0 {
+            continue
+        }
+        if opt.r > 0x7f {
+            return fmt.Errorf("%w: %s %q must be a single-byte character", ErrInvalidCopyOptions, opt.name, opt.r)
+        }
+        if opt.r == '\n' || opt.r == '\r' {
+            return fmt.Errorf("%w: %s cannot be newline or carriage return", ErrInvalidCopyOptions, opt.name)
+        }
+    }
+    if o.Delimiter != 0 && o.Delimiter == o.quote() {
+        return fmt.Errorf("%w: delimiter and quote must differ", ErrInvalidCopyOptions)
+    }
+    if strings.ContainsAny(o.Null, "\r\n") {
+        return fmt.Errorf("%w: null string cannot contain newline or carriage return", ErrInvalidCopyOptions)
+    }
+    if o.Delimiter != 0 && strings.ContainsRune(o.Null, o.Delimiter) {
+        return fmt.Errorf("%w: null string cannot contain the delimiter", ErrInvalidCopyOptions)
+    }
+    for _, r := range o.Encoding {
+        if !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+            return fmt.Errorf("%w: encoding %q is not a valid encoding name", ErrInvalidCopyOptions, o.Encoding)
+        }
+    }
+    return nil
+}
+
+func (o CSVOptions) quote() rune {
+    if o.Quote == 0 {
+        return '"'
+    }
+    return o.Quote
+}
+
+// clause renders the WITH (...) option list of a COPY ... FROM STDIN statement.
+func (o CSVOptions) clause() string {
+    opts := []string{"FORMAT csv"}
+    if o.Header {
+        opts = append(opts, "HEADER true")
+    }
+    if o.Delimiter != 0 {
+        opts = append(opts, "DELIMITER "+quoteLiteral(string(o.Delimiter)))
+    }
+    if o.Quote != 0 {
+        opts = append(opts, "QUOTE "+quoteLiteral(string(o.Quote)))
+    }
+    if o.Null != "" {
+        opts = append(opts, "NULL "+quoteLiteral(o.Null))
+    }
+    if o.Encoding != "" {
+        opts = append(opts, "ENCODING "+quoteLiteral(o.Encoding))
+    }
+    return "(" + strings.Join(opts, ", ") + ")"
+}
+
+// quoteLiteral renders s as a standard-conforming SQL string literal.
+func quoteLiteral(s string) string {
+    return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func copyFromSQL(table string, columns []string, opts CSVOptions) (string, error) {
+    name, err := tableIdent(table)
+    if err != nil {
+        return "", err
+    }
+    cols, err := columnIdents(columns)
+    if err != nil {
+        return "", err
+    }
+    var b strings.Builder
+    b.WriteString("COPY ")
+    b.WriteString(name.Sanitize())
+    if len(cols) > 0 {
+        b.WriteString(" (")
+        b.WriteString(strings.Join(cols, ", "))
+        b.WriteString(")")
+    }
+    b.WriteString(" FROM STDIN WITH ")
+    b.WriteString(opts.clause())
+    return b.String(), nil
+}
+
+// CopyFromCSV streams CSV data from r into table using COPY ... FROM STDIN and
+// returns the number of rows loaded. When columns is empty the CSV must supply
+// every column of table in order. Table and column names are quoted, so they
+// match case-sensitively; table may be schema qualified with a dot.
+// Cancelling ctx aborts the COPY and no rows are loaded.
+func (db *DB) CopyFromCSV(ctx context.Context, table string, columns []string, r io.Reader, opts CSVOptions, copyOpts ...CopyOption) (int64, error) {
+    if err := opts.validate(); err != nil {
+        return 0, err
+    }
+    query, err := copyFromSQL(table, columns, opts)
+    if err != nil {
+        return 0, err
+    }
+    cfg := newCopyConfig(copyOpts)
+    src := &csvProgressReader{ctx: ctx, r: r, quote: byte(opts.quote()), header: opts.Header, progress: cfg.progress}
+    var n int64
+    err = withConn(ctx, db.DB, func(c *serinConn) error {
+        tag, err := c.conn.PgConn().CopyFrom(ctx, src, query)
+        n = tag.RowsAffected()
+        return err
+    })
+    return n, err
+}
+
+// CopyFrom bulk loads rows from src into the named columns of table, of which
+// there must be at least one, using the binary COPY format, the fastest
+// ingestion path. The target column types are described first and
+// each value is encoded with the binary codec for that column's OID, so values
+// must already be of a matching Go type. Built-in scalar types (bool, integer
+// and float types, numeric, text/varchar, bytea, uuid, date, time, timestamp,
+// timestamptz, interval, json/jsonb, inet) and arrays of them are supported;
+// columns of types without a binary codec make the COPY fail. Names are
+// quoted as for CopyFromCSV. Use pgx.CopyFromRows or pgx.CopyFromSlice to
+// build src.
+func (db *DB) CopyFrom(ctx context.Context, table string, columns []string, src pgx.CopyFromSource, copyOpts ...CopyOption) (int64, error) {
+    if len(columns) == 0 {
+        return 0, fmt.Errorf("serin: CopyFrom into %s needs at least one column", table)
+    }
+    name, err := tableIdent(table)
+    if err != nil {
+        return 0, err
+    }
+    if _, err := columnIdents(columns); err != nil {
+        return 0, err
+    }
+    cfg := newCopyConfig(copyOpts)
+    counted := &progressSource{ctx: ctx, src: src, progress: cfg.progress}
+    var n int64
+    err = withConn(ctx, db.DB, func(c *serinConn) error {
+        var err error
+        n, err = c.conn.CopyFrom(ctx, name, columns, counted)
+        return err
+    })
+    return n, err
+}
+
+// CopyOption configures the COPY helpers.
+type CopyOption func(*copyConfig)
+
+type copyConfig struct {
+    progress progress
+}
+
+func newCopyConfig(opts []CopyOption) copyConfig {
+    var cfg copyConfig
+    for _, opt := range opts {
+        opt(&cfg)
+    }
+    return cfg
+}
+
+// WithCopyProgress calls fn with the number of rows sent so far every time
+// another every rows have been sent. fn runs on a goroutine internal to the
+// driver, not the one that called CopyFrom or CopyFromCSV, so it must
+// synchronise any state it shares with other goroutines. Cancelling the
+// COPY's context from fn aborts it. For CSV input rows are counted as record
+// terminators outside quoted fields.
+func WithCopyProgress(every int64, fn func(rows int64)) CopyOption {
+    return func(c *copyConfig) { c.progress = progress{every: every, fn: fn} }
+}
+
+type progress struct {
+    every int64
+    fn    func(rows int64)
+    rows  int64
+}
+
+func (p *progress) add(n int64) {
+    if p.fn == nil || p.every <= 0 {
+        p.rows += n
+        return
+    }
+    for ; n > 0; n-- {
+        p.rows++
+        if p.rows%p.every == 0 {
+            p.fn(p.rows)
+        }
+    }
+}
+
+// progressSource counts rows of a binary COPY and stops it once ctx is done,
+// so pgx sends CopyFail and the server discards everything received.
+type progressSource struct {
+    ctx      context.Context
+    src      pgx.CopyFromSource
+    progress progress
+}
+
+func (s *progressSource) Next() bool {
+    if s.ctx.Err() != nil || !s.src.Next() {
+        return false
+    }
+    s.progress.add(1)
+    return true
+}
+
+func (s *progressSource) Values() ([]any, error) { return s.src.Values() }
+
+func (s *progressSource) Err() error {
+    if err := s.ctx.Err(); err != nil {
+        return err
+    }
+    return s.src.Err()
+}
+
+// csvProgressReader counts CSV records as they are sent and fails the COPY
+// once ctx is done.
+type csvProgressReader struct {
+    ctx      context.Context
+    r        io.Reader
+    quote    byte
+    header   bool
+    inQuote  bool
+    progress progress
+}
+
+func (r *csvProgressReader) Read(p []byte) (int, error) {
+    if err := r.ctx.Err(); err != nil {
+        return 0, err
+    }
+    n, err := r.r.Read(p)
+    var records int64
+    for _, b := range p[:n] {
+        switch {
+        case b == r.quote:
+            r.inQuote = !r.inQuote
+        case b == '\n' && !r.inQuote:
+            if r.header {
+                r.header = false
+                continue
+            }
+            records++
+        }
+    }
+    r.progress.add(records)
+    return n, err
+}
@@ -0,0 +1,253 @@
+package driver
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+func TestCopyFromSQL(t *testing.T) {
+    got, err := copyFromSQL("app.People", []string{"id", `say "hi"`}, CSVOptions{Header: true, Delimiter: '\t', Null: `\N`, Encoding: "UTF8"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := "COPY \"app\".\"People\" (\"id\", \"say \"\"hi\"\"\") FROM STDIN WITH (FORMAT csv, HEADER true, DELIMITER '\t', NULL '\\N', ENCODING 'UTF8')"
+    if got != want {
+        t.Errorf("got  %s\nwant %s", got, want)
+    }
+    if got, _ := copyFromSQL("people", nil, CSVOptions{Quote: '\''}); got != `COPY "people" FROM STDIN WITH (FORMAT csv, QUOTE '''')` {
+        t.Errorf("unexpected quote rendering: %s", got)
+    }
+    if _, err := copyFromSQL("people", []string{"id\x00; DROP TABLE people"}, CSVOptions{}); !errors.Is(err, ErrInvalidIdentifier) {
+        t.Errorf("NUL byte in column accepted: %v", err)
+    }
+}
+
+func TestCSVOptionsValidate(t *testing.T) {
+    bad := []CSVOptions{
+        {Delimiter: '\n'},
+        {Quote: '\r'},
+        {Delimiter: '§'},
+        {Delimiter: '|', Quote: '|'},
+        {Delimiter: '"'},
+        {Null: "a\nb"},
+        {Delimiter: ';', Null: "x;y"},
+        {Encoding: "UTF8'; DROP TABLE x"},
+    }
+    for _, o := range bad {
+        if err := o.validate(); !errors.Is(err, ErrInvalidCopyOptions) {
+            t.Errorf("%+v: got %v, want ErrInvalidCopyOptions", o, err)
+        }
+    }
+    if err := (CSVOptions{Header: true, Delimiter: '\t', Quote: '\'', Encoding: "LATIN1"}).validate(); err != nil {
+        t.Errorf("valid options rejected: %v", err)
+    }
+}
+
+func TestCopyFromCSV(t *testing.T) {
+    db := Wrap(testDB(t))
+    mustExec(t, db.DB, "DROP TABLE IF EXISTS serin_copy_csv", "CREATE TABLE serin_copy_csv (id int, name text, note text)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_copy_csv") })
+    ctx := context.Background()
+
+    tsv := "id\tname\tnote\n1\talice\t\\N\n2\tbob\tplain\n"
+    n, err := db.CopyFromCSV(ctx, "serin_copy_csv", []string{"id", "name", "note"}, strings.NewReader(tsv), CSVOptions{Header: true, Delimiter: '\t', Null: `\N`})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n != 2 {
+        t.Fatalf("tab-delimited import loaded %d rows, want 2", n)
+    }
+
+    quoted := "3,\"smith, jane\",\"said \"\"hi\"\"\"\n4,'single',\n"
+    n, err = db.CopyFromCSV(ctx, "serin_copy_csv", []string{"id", "name", "note"}, strings.NewReader(quoted), CSVOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n != 2 {
+        t.Fatalf("quoted import loaded %d rows, want 2", n)
+    }
+
+    var name, note string
+    if err := db.QueryRow("SELECT name, note FROM serin_copy_csv WHERE id = 3").Scan(&name, &note); err != nil {
+        t.Fatal(err)
+    }
+    if name != "smith, jane" || note != `said "hi"` {
+        t.Errorf("quoted fields parsed as %q, %q", name, note)
+    }
+    var nulls int
+    if err := db.QueryRow("SELECT count(*) FROM serin_copy_csv WHERE note IS NULL").Scan(&nulls); err != nil {
+        t.Fatal(err)
+    }
+    if nulls != 2 {
+        t.Errorf("got %d NULL notes, want 2", nulls)
+    }
+}
+
+func TestCopyFromBinary(t *testing.T) {
+    db := Wrap(testDB(t))
+    mustExec(t, db.DB, "DROP TABLE IF EXISTS serin_copy_bin", "CREATE TABLE serin_copy_bin (id int8, name text, at timestamptz, score float8)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_copy_bin") })
+
+    at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+    n, err := db.CopyFrom(context.Background(), "public.serin_copy_bin", []string{"id", "name", "at", "score"}, pgx.CopyFromRows([][]any{
+        {int64(1), "alice", at, 1.5},
+        {int64(2), nil, nil, nil},
+    }))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n != 2 {
+        t.Fatalf("loaded %d rows, want 2", n)
+    }
+    var got time.Time
+    if err := db.QueryRow("SELECT at FROM serin_copy_bin WHERE id = 1").Scan(&got); err != nil {
+        t.Fatal(err)
+    }
+    if !got.Equal(at) {
+        t.Errorf("timestamp round tripped as %v", got)
+    }
+}
+
+func TestCopyFromNoColumns(t *testing.T) {
+    c, err := NewConnector("host=127.0.0.1 port=1 user=alice sslmode=disable")
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := Wrap(sql.OpenDB(c))
+    defer db.Close()
+    _, err = db.CopyFrom(context.Background(), "serin_copy_bin", nil, pgx.CopyFromRows(nil))
+    if err == nil || !strings.Contains(err.Error(), "serin_copy_bin") {
+        t.Errorf("got %v, want an error naming the table", err)
+    }
+}
+
+const benchCopyRows = 100000
+
+func benchCopyTable(b *testing.B) *DB {
+    db := Wrap(testDB(b))
+    mustExec(b, db.DB, "DROP TABLE IF EXISTS serin_copy_bench", "CREATE UNLOGGED TABLE serin_copy_bench (id int8, name text, at timestamptz, score float8)")
+    b.Cleanup(func() { db.Exec("DROP TABLE serin_copy_bench") })
+    return db
+}
+
+func BenchmarkCopyFromBinary(b *testing.B) {
+    db := benchCopyTable(b)
+    at := time.Now()
+    rows := make([][]any, benchCopyRows)
+    for i := range rows {
+        rows[i] = []any{int64(i), fmt.Sprintf("name-%d", i), at, float64(i) / 3}
+    }
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := db.CopyFrom(context.Background(), "serin_copy_bench", []string{"id", "name", "at", "score"}, pgx.CopyFromRows(rows)); err != nil {
+            b.Fatal(err)
+        }
+    }
+    b.ReportMetric(float64(benchCopyRows*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func BenchmarkCopyFromCSV(b *testing.B) {
+    db := benchCopyTable(b)
+    at := time.Now().Format(time.RFC3339Nano)
+    var buf bytes.Buffer
+    for i := 0; i < benchCopyRows; i++ {
+        fmt.Fprintf(&buf, "%d,name-%d,%s,%g\n", i, i, at, float64(i)/3)
+    }
+    data := buf.Bytes()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := db.CopyFromCSV(context.Background(), "serin_copy_bench", []string{"id", "name", "at", "score"}, bytes.NewReader(data), CSVOptions{}); err != nil {
+            b.Fatal(err)
+        }
+    }
+    b.ReportMetric(float64(benchCopyRows*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func TestCSVProgressReaderCountsRecords(t *testing.T) {
+    var calls []int64
+    r := &csvProgressReader{
+        ctx:      context.Background(),
+        r:        strings.NewReader("id,note\n1,\"multi\nline\"\n2,\"say \"\"hi\"\"\"\n3,plain\n"),
+        quote:    '"',
+        header:   true,
+        progress: progress{every: 2, fn: func(n int64) { calls = append(calls, n) }},
+    }
+    buf := make([]byte, 5)
+    for {
+        if _, err := r.Read(buf); err != nil {
+            break
+        }
+    }
+    if r.progress.rows != 3 {
+        t.Errorf("counted %d records, want 3", r.progress.rows)
+    }
+    if len(calls) != 1 || calls[0] != 2 {
+        t.Errorf("progress calls %v, want [2]", calls)
+    }
+}
+
+func TestCopyCancelledMidImport(t *testing.T) {
+    db := Wrap(testDB(t))
+    mustExec(t, db.DB, "DROP TABLE IF EXISTS serin_copy_cancel", "CREATE TABLE serin_copy_cancel (id int8)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_copy_cancel") })
+
+    count := func() int {
+        var n int
+        if err := db.QueryRow("SELECT count(*) FROM serin_copy_cancel").Scan(&n); err != nil {
+            t.Fatal(err)
+        }
+        return n
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    var seen int64
+    cancelAt := WithCopyProgress(1000, func(n int64) {
+        seen = n
+        if n == 5000 {
+            cancel()
+        }
+    })
+    _, err := db.CopyFrom(ctx, "serin_copy_cancel", []string{"id"}, pgx.CopyFromSlice(1_000_000, func(i int) ([]any, error) {
+        return []any{int64(i)}, nil
+    }), cancelAt)
+    if err == nil {
+        t.Fatal("binary COPY survived cancellation")
+    }
+    if seen != 5000 {
+        t.Errorf("last progress report %d, want 5000", seen)
+    }
+    if n := count(); n != 0 {
+        t.Errorf("%d rows committed by a cancelled binary COPY", n)
+    }
+
+    ctx, cancel = context.WithCancel(context.Background())
+    pr, pw := io.Pipe()
+    go func() {
+        for i := 0; ; i++ {
+            if _, err := fmt.Fprintf(pw, "%d\n", i); err != nil {
+                return
+            }
+        }
+    }()
+    defer pr.Close()
+    _, err = db.CopyFromCSV(ctx, "serin_copy_cancel", []string{"id"}, pr, CSVOptions{}, WithCopyProgress(1000, func(n int64) {
+        if n == 5000 {
+            cancel()
+        }
+    }))
+    if err == nil {
+        t.Fatal("CSV COPY survived cancellation")
+    }
+    if n := count(); n != 0 {
+        t.Errorf("%d rows committed by a cancelled CSV COPY", n)
+    }
+}
@@ -0,0 +1,146 @@
+package driver
+
+import (
+    "context"
+    "database/sql/driver"
+    "encoding/csv"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// QueryToCSV runs query and writes its result set to w as CSV: a header row
+// of column names, then one record per row, written as rows arrive so large
+// results are streamed. Pass a CSVOptions before the query arguments to set
+// the Delimiter or the Null token, which are empty fields by default; it is
+// not bound as a parameter. The header is always written, Quote must be '"'
+// and Encoding empty, so the same options with Header set load the output
+// back with CopyFromCSV.
+//
+// Values are formatted as text: timestamptz as RFC 3339, timestamp as RFC
+// 3339 without an offset, date as YYYY-MM-DD, bytea as \x followed by hex,
+// json as its text, arrays as {a,b} array literals, uuid in its canonical
+// form, and numeric, interval and other types in the text form of their pgx
+// codec, which the server reads back. A value equal to the Null token is
+// written unchanged, so pick one that cannot occur in the data, such as \N,
+// when NULLs must be told apart. For exports without per-row processing, COPY
+// TO is faster.
+//
+//	err := db.QueryToCSV(ctx, w, "SELECT id, email, created_at FROM users WHERE org = $1", driver.CSVOptions{Null: `\N`}, org)
+func (db *DB) QueryToCSV(ctx context.Context, w io.Writer, query string, args ...any) error {
+    var opts CSVOptions
+    if len(args) > 0 {
+        if o, ok := args[0].(CSVOptions); ok {
+            opts, args = o, args[1:]
+        }
+    }
+    if err := opts.validate(); err != nil {
+        return err
+    }
+    if opts.quote() != '"' || opts.Encoding != "" {
+        return fmt.Errorf("%w: QueryToCSV supports neither a custom quote nor an encoding", ErrInvalidCopyOptions)
+    }
+    rows, err := db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    types, err := rows.ColumnTypes()
+    if err != nil {
+        return err
+    }
+    out := csv.NewWriter(w)
+    if opts.Delimiter != 0 {
+        out.Comma = opts.Delimiter
+    }
+    record := make([]string, len(types))
+    for i, t := range types {
+        record[i] = t.Name()
+    }
+    if err := out.Write(record); err != nil {
+        return err
+    }
+    values := make([]any, len(types))
+    ptrs := make([]any, len(types))
+    for i := range values {
+        ptrs[i] = &values[i]
+    }
+    for rows.Next() {
+        if err := rows.Scan(ptrs...); err != nil {
+            return err
+        }
+        for i, v := range values {
+            if record[i], err = csvField(v, types[i].DatabaseTypeName(), opts.Null); err != nil {
+                return fmt.Errorf("serin: formatting column %q: %w", types[i].Name(), err)
+            }
+        }
+        if err := out.Write(record); err != nil {
+            return err
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return err
+    }
+    out.Flush()
+    return out.Error()
+}
+
+// csvField formats a value of a column of type typeName for QueryToCSV.
+func csvField(v any, typeName, null string) (string, error) {
+    switch v := v.(type) {
+    case nil:
+        return null, nil
+    case string:
+        return v, nil
+    case []byte:
+        if typeName == "BYTEA" {
+            return `\x` + hex.EncodeToString(v), nil
+        }
+        return string(v), nil
+    case time.Time:
+        switch typeName {
+        case "DATE":
+            return v.Format(time.DateOnly), nil
+        case "TIMESTAMP":
+            return v.Format("2006-01-02T15:04:05.999999999"), nil
+        }
+        return v.Format(time.RFC3339Nano), nil
+    case bool:
+        return strconv.FormatBool(v), nil
+    case int64:
+        return strconv.FormatInt(v, 10), nil
+    case float64:
+        return strconv.FormatFloat(v, 'g', -1, 64), nil
+    case float32:
+        return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+    case [16]byte:
+        return uuidString(v), nil
+    case driver.Valuer:
+        // pgtype.Numeric, Interval and the like give their text form.
+        dv, err := v.Value()
+        if err != nil {
+            return "", err
+        }
+        if _, ok := dv.(driver.Valuer); !ok {
+            return csvField(dv, typeName, null)
+        }
+    }
+    m := typeMaps.Get().(*pgtype.Map)
+    defer typeMaps.Put(m)
+    if t, ok := m.TypeForName(strings.ToLower(typeName)); ok {
+        if text, err := m.Encode(t.OID, pgtype.TextFormatCode, v, nil); err == nil && text != nil {
+            return string(text), nil
+        }
+    }
+    return fmt.Sprint(v), nil
+}
+
+// uuidString formats u, a uuid as pgx decodes it, in its canonical form.
+func uuidString(u [16]byte) string {
+    return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
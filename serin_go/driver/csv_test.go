@@ -0,0 +1,141 @@
+package driver
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "encoding/csv"
+    "errors"
+    "net"
+    "net/netip"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgproto3"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCSVField(t *testing.T) {
+    at := time.Date(2024, 5, 6, 7, 8, 9, 120000000, time.FixedZone("", 2*3600))
+    var num pgtype.Numeric
+    if err := num.Scan("123.4500"); err != nil {
+        t.Fatal(err)
+    }
+    for _, tc := range []struct {
+        v        any
+        typeName string
+        want     string
+    }{
+        {nil, "TEXT", `\N`},
+        {"a,b", "TEXT", "a,b"},
+        {[]byte{0xde, 0xad}, "BYTEA", `\xdead`},
+        {[]byte(`{"k": 1}`), "JSONB", `{"k": 1}`},
+        {at, "TIMESTAMPTZ", "2024-05-06T07:08:09.12+02:00"},
+        {at, "TIMESTAMP", "2024-05-06T07:08:09.12"},
+        {at, "DATE", "2024-05-06"},
+        {true, "BOOL", "true"},
+        {int64(-42), "INT8", "-42"},
+        {0.1, "FLOAT8", "0.1"},
+        {float32(0.1), "FLOAT4", "0.1"},
+        {num, "NUMERIC", "123.4500"},
+        {[16]byte{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11}, "UUID", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+        {pgtype.Interval{Days: 1, Microseconds: 2 * 3600e6, Valid: true}, "INTERVAL", "1 day 02:00:00.000000"},
+        {netip.MustParsePrefix("10.1.2.3/32"), "INET", "10.1.2.3/32"},
+        {int32(5), "INT4", "5"},
+    } {
+        if got, err := csvField(tc.v, tc.typeName, `\N`); err != nil || got != tc.want {
+            t.Errorf("%s %#v: got %q, %v; want %q", tc.typeName, tc.v, got, err, tc.want)
+        }
+    }
+}
+
+// csvServer fakes a server answering every simple query with a fixed result.
+func csvServer(t *testing.T) (host, port string) {
+    return fakeServer(t, func(c net.Conn) {
+        be := pgproto3.NewBackend(c, c)
+        if _, err := be.ReceiveStartupMessage(); err != nil {
+            return
+        }
+        be.Send(&pgproto3.AuthenticationOk{})
+        be.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+        be.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+        be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+        be.Flush()
+        for {
+            msg, err := be.Receive()
+            if err != nil {
+                return
+            }
+            q, ok := msg.(*pgproto3.Query)
+            if !ok {
+                return
+            }
+            if strings.Contains(q.String, "people") {
+                field := func(name string, oid uint32) pgproto3.FieldDescription {
+                    return pgproto3.FieldDescription{Name: []byte(name), DataTypeOID: oid, DataTypeSize: -1, TypeModifier: -1}
+                }
+                be.Send(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{field("id", pgtype.Int8OID), field("name", pgtype.TextOID), field("joined", pgtype.TimestamptzOID)}})
+                be.Send(&pgproto3.DataRow{Values: [][]byte{[]byte("1"), []byte(`Smith; "J"`), []byte("2024-05-06 07:08:09+00")}})
+                be.Send(&pgproto3.DataRow{Values: [][]byte{[]byte("2"), nil, nil}})
+                be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 2")})
+            } else {
+                be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 0")})
+            }
+            be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+            be.Flush()
+        }
+    })
+}
+
+func TestQueryToCSVRoundTrip(t *testing.T) {
+    host, port := csvServer(t)
+    c, err := NewConnector("host=" + host + " port=" + port + " user=alice sslmode=disable")
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := Wrap(sql.OpenDB(c))
+    defer db.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    var buf bytes.Buffer
+    if err := db.QueryToCSV(ctx, &buf, "SELECT * FROM people", CSVOptions{Delimiter: ';', Null: `\N`}, SimpleProtocol); err != nil {
+        t.Fatal(err)
+    }
+    r := csv.NewReader(&buf)
+    r.Comma = ';'
+    records, err := r.ReadAll()
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := [][]string{{"id", "name", "joined"}, {"1", `Smith; "J"`, "2024-05-06T07:08:09Z"}, {"2", `\N`, `\N`}}
+    if !reflect.DeepEqual(records, want) {
+        t.Errorf("got %q\nwant %q", records, want)
+    }
+
+    if err := db.QueryToCSV(ctx, &buf, "SELECT * FROM people", CSVOptions{Quote: '\''}); !errors.Is(err, ErrInvalidCopyOptions) {
+        t.Errorf("custom quote: got %v", err)
+    }
+}
+
+func TestQueryToCSVTypes(t *testing.T) {
+    db := Wrap(testDB(t))
+    var buf bytes.Buffer
+    err := db.QueryToCSV(context.Background(), &buf, `SELECT $1::int AS n, '\xdead'::bytea AS b, DATE '2024-05-06' AS d,
+        TIMESTAMP '2024-05-06 07:08:09.5' AS ts, '{"k": [1, 2]}'::jsonb AS j, ARRAY['a', 'b c'] AS arr, NULL::text AS missing,
+        123.4500::numeric AS num, 'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'::uuid AS id, INTERVAL '1 day 2 hours' AS iv`, 7)
+    if err != nil {
+        t.Fatal(err)
+    }
+    records, err := csv.NewReader(&buf).ReadAll()
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := [][]string{{"n", "b", "d", "ts", "j", "arr", "missing", "num", "id", "iv"},
+        {"7", `\xdead`, "2024-05-06", "2024-05-06T07:08:09.5", `{"k": [1, 2]}`, "{a,b c}", "", "123.4500", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "1 day 02:00:00.000000"}}
+    if !reflect.DeepEqual(records, want) {
+        t.Errorf("got %q\nwant %q", records, want)
+    }
+}
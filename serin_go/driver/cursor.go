@@ -0,0 +1,145 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+)
+
+// cursorName names the cursor of a Cursor. Each Cursor has its own
+// transaction and so its own connection, which never holds two at once, and a
+// fixed name keeps the DECLARE and FETCH statements cacheable.
+const cursorName = "serin_cursor"
+
+// Cursor iterates over the result of a query through a server-side cursor,
+// fetching batchSize rows at a time, so large results can be paged through
+// without holding them in memory. It is used like sql.Rows: call Next until it
+// returns false, Scan each row, then check Err.
+//
+// The cursor lives in a transaction that the Cursor opens and holds for its
+// whole lifetime, pinning one pooled connection. The rows come from the
+// snapshot taken when the cursor was opened, so concurrent writes do not
+// shift or repeat rows between batches. Once the last row is read, or on an
+// error, the cursor is closed and the transaction ended; call Close to stop
+// early. Until then the open transaction holds back vacuum, so do not keep a
+// Cursor open longer than needed.
+type Cursor struct {
+    ctx   context.Context
+    tx    *sql.Tx
+    fetch string
+    batch int
+    rows  *sql.Rows // current batch
+    n     int       // rows read from the current batch
+    done  bool
+    err   error
+}
+
+// OpenCursor begins a transaction on db and declares a cursor for query in
+// it. query must be a SELECT or VALUES statement; args bind to its
+// placeholders. ctx governs the whole iteration: when it is done the
+// transaction is rolled back and Next fails.
+//
+//	cur, err := db.OpenCursor(ctx, 100, "SELECT id, name FROM users WHERE active = $1 ORDER BY id", true)
+//	if err != nil { ... }
+//	defer cur.Close()
+//	for cur.Next() {
+//	    err := cur.Scan(&id, &name)
+//	    ...
+//	}
+//	err = cur.Err()
+func (db *DB) OpenCursor(ctx context.Context, batchSize int, query string, args ...any) (*Cursor, error) {
+    if batchSize <= 0 {
+        return nil, fmt.Errorf("serin: OpenCursor needs a positive batch size, got %d", batchSize)
+    }
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := tx.ExecContext(ctx, "DECLARE "+cursorName+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+        tx.Rollback()
+        return nil, err
+    }
+    return &Cursor{ctx: ctx, tx: tx, fetch: "FETCH " + strconv.Itoa(batchSize) + " FROM " + cursorName, batch: batchSize}, nil
+}
+
+// Next advances to the next row, fetching the next batch when the current one
+// is used up. It returns false at the end of the result or on an error, and
+// the cursor is closed either way.
+func (c *Cursor) Next() bool {
+    for !c.done {
+        if c.rows == nil {
+            rows, err := c.tx.QueryContext(c.ctx, c.fetch)
+            if err != nil {
+                c.finish(err)
+                return false
+            }
+            c.rows, c.n = rows, 0
+        }
+        if c.rows.Next() {
+            c.n++
+            return true
+        }
+        err := c.rows.Close()
+        if err == nil {
+            err = c.rows.Err()
+        }
+        last := c.n < c.batch
+        c.rows = nil
+        if err != nil || last {
+            c.finish(err)
+        }
+    }
+    return false
+}
+
+// Scan copies the columns of the current row into dest, as sql.Rows.Scan.
+func (c *Cursor) Scan(dest ...any) error {
+    if c.rows == nil {
+        return errors.New("serin: Scan called without a successful Next")
+    }
+    return c.rows.Scan(dest...)
+}
+
+// Columns returns the column names of the current batch.
+func (c *Cursor) Columns() ([]string, error) {
+    if c.rows == nil {
+        return nil, errors.New("serin: Columns called without a successful Next")
+    }
+    return c.rows.Columns()
+}
+
+// Err returns the error that ended the iteration, if any.
+func (c *Cursor) Err() error { return c.err }
+
+// Close closes the cursor and ends its transaction. It is safe to call more
+// than once and after the iteration completed.
+func (c *Cursor) Close() error {
+    c.finish(nil)
+    return c.err
+}
+
+// finish closes the cursor and commits its transaction, or rolls it back when
+// err ended the iteration. The first error is kept for Err.
+func (c *Cursor) finish(err error) {
+    if c.done {
+        return
+    }
+    c.done = true
+    if c.rows != nil {
+        c.rows.Close()
+        c.rows = nil
+    }
+    if err != nil {
+        c.err = err
+        c.tx.Rollback()
+        return
+    }
+    if _, err := c.tx.ExecContext(c.ctx, "CLOSE "+cursorName); err != nil {
+        c.err = err
+        c.tx.Rollback()
+        return
+    }
+    c.err = c.tx.Commit()
+}
@@ -0,0 +1,73 @@
+package driver
+
+import (
+    "context"
+    "testing"
+)
+
+func TestCursorBatches(t *testing.T) {
+    db := Wrap(testDB(t))
+    mustExec(t, db.DB, "DROP TABLE IF EXISTS serin_cursor_rows", "CREATE TABLE serin_cursor_rows (id int PRIMARY KEY)",
+        "INSERT INTO serin_cursor_rows SELECT generate_series(1, 1000)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_cursor_rows") })
+    ctx := context.Background()
+
+    cur, err := db.OpenCursor(ctx, 100, "SELECT id FROM serin_cursor_rows WHERE id > $1 ORDER BY id", 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer cur.Close()
+    var n int
+    for cur.Next() {
+        var id int
+        if err := cur.Scan(&id); err != nil {
+            t.Fatal(err)
+        }
+        n++
+        if id != n {
+            t.Fatalf("row %d has id %d", n, id)
+        }
+        if n == 150 {
+            // Rows written after the cursor opened are not seen.
+            mustExec(t, db.DB, "INSERT INTO serin_cursor_rows VALUES (1001)")
+        }
+    }
+    if err := cur.Err(); err != nil {
+        t.Fatal(err)
+    }
+    if n != 1000 {
+        t.Errorf("read %d rows, want 1000", n)
+    }
+    if inUse := db.Stats().InUse; inUse != 0 {
+        t.Errorf("%d connections still held after the last row", inUse)
+    }
+}
+
+func TestCursorCloseEarly(t *testing.T) {
+    db := Wrap(testDB(t))
+    ctx := context.Background()
+    cur, err := db.OpenCursor(ctx, 10, "SELECT generate_series(1, 1000)")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for i := 0; i < 15 && cur.Next(); i++ {
+    }
+    if err := cur.Close(); err != nil {
+        t.Fatal(err)
+    }
+    if cur.Next() {
+        t.Error("Next after Close")
+    }
+    if err := cur.Close(); err != nil {
+        t.Errorf("second Close: %v", err)
+    }
+    if inUse := db.Stats().InUse; inUse != 0 {
+        t.Errorf("%d connections still held after Close", inUse)
+    }
+}
+
+func TestOpenCursorRejectsBatchSize(t *testing.T) {
+    if _, err := Wrap(nil).OpenCursor(context.Background(), 0, "SELECT 1"); err == nil {
+        t.Error("zero batch size accepted")
+    }
+}
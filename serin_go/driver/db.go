@@ -0,0 +1,12 @@
+package driver
+
+import "database/sql"
+
+// DB wraps a *sql.DB opened with the serin driver and adds SerinDB specific
+// helpers that need the underlying pgx connection.
+type DB struct {
+    *sql.DB
+}
+
+// Wrap returns a DB exposing the serin helpers on top of db.
+func Wrap(db *sql.DB) *DB { return &DB{DB: db} }
@@ -0,0 +1,106 @@
+package driver
+
+import (
+    "context"
+    "fmt"
+    "slices"
+    "strings"
+
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// domainTypesSQL finds the domains among a set of type OIDs together with
+// the base type they ultimately stand for, following domains over domains.
+const domainTypesSQL = `WITH RECURSIVE d AS (
+    SELECT oid, typname, typarray, typbasetype AS base FROM pg_type WHERE oid = ANY($1) AND typtype = 'd'
+    UNION ALL
+    SELECT d.oid, d.typname, d.typarray, t.typbasetype FROM d JOIN pg_type t ON t.oid = d.base AND t.typtype = 'd'
+)
+SELECT d.oid, d.typname, d.typarray, d.base FROM d JOIN pg_type b ON b.oid = d.base AND b.typtype <> 'd'`
+
+// resolveDomains registers the domains among the parameter and result types
+// of a freshly prepared statement on the connection's type map, so their
+// values are encoded and decoded with the codec of their base type. OIDs the
+// type map does not know are looked up once per connection and remembered in
+// c.domains; a lookup that fails is tried again for the next statement.
+// Only statements prepared for the statement cache are resolved: without it,
+// or with SimpleProtocol, no description is at hand before the rows arrive,
+// and the connection is busy with them until they are closed.
+func (c *serinConn) resolveDomains(ctx context.Context, sd *pgconn.StatementDescription) error {
+    m := c.conn.TypeMap()
+    var unknown []uint32
+    check := func(oid uint32) {
+        if _, ok := m.TypeForOID(oid); ok {
+            return
+        }
+        if _, ok := c.domains[oid]; ok || slices.Contains(unknown, oid) {
+            return
+        }
+        unknown = append(unknown, oid)
+    }
+    for _, oid := range sd.ParamOIDs {
+        check(oid)
+    }
+    for _, f := range sd.Fields {
+        check(f.DataTypeOID)
+    }
+    if len(unknown) == 0 {
+        return nil
+    }
+    rows, err := c.conn.Query(ctx, domainTypesSQL, unknown)
+    if err != nil {
+        return fmt.Errorf("serin: looking up domain types: %w", err)
+    }
+    defer rows.Close()
+    if c.domains == nil {
+        c.domains = make(map[uint32]string)
+    }
+    for rows.Next() {
+        var oid, array, base uint32
+        var name string
+        if err := rows.Scan(&oid, &name, &array, &base); err != nil {
+            return fmt.Errorf("serin: looking up domain types: %w", err)
+        }
+        bt, ok := m.TypeForOID(base)
+        if !ok {
+            continue
+        }
+        c.domains[oid] = name
+        if _, taken := m.TypeForName(name); taken {
+            // Keep the name of a built-in or earlier registered type intact.
+            name = fmt.Sprintf("%s_%d", name, oid)
+        }
+        dt := &pgtype.Type{Name: name, OID: oid, Codec: bt.Codec}
+        m.RegisterType(dt)
+        if array != 0 {
+            c.domains[array] = "_" + c.domains[oid]
+            m.RegisterType(&pgtype.Type{Name: "_" + name, OID: array, Codec: &pgtype.ArrayCodec{ElementType: dt}})
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("serin: looking up domain types: %w", err)
+    }
+    // Only now that the lookup succeeded are the rest known not to be domains.
+    for _, oid := range unknown {
+        if _, ok := c.domains[oid]; !ok {
+            c.domains[oid] = ""
+        }
+    }
+    return nil
+}
+
+// ColumnTypeDatabaseTypeName returns the upper-case name of the column's
+// type, such as "INT4", or of the domain it is declared with once
+// resolveDomains has seen it. Types unknown to the connection, including
+// domains used without the statement cache, are reported by OID.
+func (r *serinRows) ColumnTypeDatabaseTypeName(index int) string {
+    oid := r.pgRows.FieldDescriptions()[index].DataTypeOID
+    if name := r.conn.domains[oid]; name != "" {
+        return strings.ToUpper(name)
+    }
+    if t, ok := r.conn.conn.TypeMap().TypeForOID(oid); ok {
+        return strings.ToUpper(t.Name)
+    }
+    return fmt.Sprint(oid)
+}
@@ -0,0 +1,118 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "strconv"
+    "testing"
+)
+
+// createDomains creates the serin_domains table with columns of two domains.
+func createDomains(t *testing.T, db *sql.DB) {
+    t.Helper()
+    mustExec(t, db,
+        "DROP TABLE IF EXISTS serin_domains",
+        "DROP DOMAIN IF EXISTS serin_email, serin_positive",
+        `CREATE DOMAIN serin_email AS text CHECK (VALUE LIKE '%@%')`,
+        "CREATE DOMAIN serin_positive AS int4 CHECK (VALUE > 0)",
+        "CREATE TABLE serin_domains (email serin_email, n serin_positive)",
+        "INSERT INTO serin_domains VALUES ('a@example.com', 3)")
+    t.Cleanup(func() {
+        db.Exec("DROP TABLE serin_domains")
+        db.Exec("DROP DOMAIN serin_email, serin_positive")
+    })
+}
+
+func TestDomainTypes(t *testing.T) {
+    db, _ := testConnectorDB(t)
+    db.SetMaxOpenConns(1)
+    createDomains(t, db)
+
+    for i := 0; i < 2; i++ {
+        rows, err := db.Query("SELECT email, n FROM serin_domains WHERE email = $1", "a@example.com")
+        if err != nil {
+            t.Fatal(err)
+        }
+        types, err := rows.ColumnTypes()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := types[0].DatabaseTypeName() + "," + types[1].DatabaseTypeName(); got != "SERIN_EMAIL,SERIN_POSITIVE" {
+            t.Errorf("type names %s", got)
+        }
+        if !rows.Next() {
+            t.Fatalf("no row: %v", rows.Err())
+        }
+        var email string
+        var n int32
+        if err := rows.Scan(&email, &n); err != nil {
+            t.Fatal(err)
+        }
+        if email != "a@example.com" || n != 3 {
+            t.Errorf("scanned %q, %d", email, n)
+        }
+        rows.Close()
+    }
+}
+
+// A failed lookup must not leave the domains marked as looked up.
+func TestDomainLookupFailureRetried(t *testing.T) {
+    db, _ := testConnectorDB(t)
+    createDomains(t, db)
+    ctx := context.Background()
+    err := withConn(ctx, db, func(c *serinConn) error {
+        sd, err := c.conn.Prepare(ctx, "", "SELECT email FROM serin_domains")
+        if err != nil {
+            return err
+        }
+        canceled, cancel := context.WithCancel(ctx)
+        cancel()
+        if err := c.resolveDomains(canceled, sd); err == nil {
+            t.Error("lookup with a canceled context succeeded")
+        }
+        if err := c.resolveDomains(ctx, sd); err != nil {
+            return err
+        }
+        if got := c.domains[sd.Fields[0].DataTypeOID]; got != "serin_email" {
+            t.Errorf("domain name %q after retrying the lookup", got)
+        }
+        return nil
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+}
+
+// Without the statement cache statements are not described ahead of time, so
+// domains are left unresolved: values come back in text form and the type is
+// reported by OID.
+func TestDomainTypesUncached(t *testing.T) {
+    db, _ := testConnectorDB(t, WithStatementCacheCapacity(0))
+    db.SetMaxOpenConns(1)
+    createDomains(t, db)
+
+    rows, err := db.Query("SELECT email, n FROM serin_domains WHERE email = $1", "a@example.com")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer rows.Close()
+    types, err := rows.ColumnTypes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, ct := range types {
+        if _, err := strconv.ParseUint(ct.DatabaseTypeName(), 10, 32); err != nil {
+            t.Errorf("%s: type name %q, want an OID", ct.Name(), ct.DatabaseTypeName())
+        }
+    }
+    if !rows.Next() {
+        t.Fatalf("no row: %v", rows.Err())
+    }
+    var email, n string
+    if err := rows.Scan(&email, &n); err != nil {
+        t.Fatal(err)
+    }
+    if email != "a@example.com" || n != "3" {
+        t.Errorf("scanned %q, %q", email, n)
+    }
+}
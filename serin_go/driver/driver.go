@@ -5,7 +5,15 @@ import (
     "context"
     "database/sql"
     "database/sql/driver"
+    "errors"
+    "io"
+    "log/slog"
+    "sync/atomic"
+    "time"
+
     "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgtype"
 )
 
 func init() {
@@ -15,33 +23,208 @@ func init() {
 type serinDriver struct{}
 
 func (d *serinDriver) Open(name string) (driver.Conn, error) {
-    cfg, err := pgx.ParseConfig(name)
-    if err != nil {
-        return nil, err
-    }
-    conn, err := pgx.ConnectConfig(context.Background(), cfg)
+    c, err := d.OpenConnector(name)
     if err != nil {
         return nil, err
     }
-    return &serinConn{conn: conn}, nil
+    return c.Connect(context.Background())
+}
+
+func (d *serinDriver) OpenConnector(name string) (driver.Connector, error) {
+    return NewConnector(name)
 }
 
 type serinConn struct {
-    conn *pgx.Conn
+    conn      *pgx.Conn
+    connector *Connector
+    stmts     *stmtCache
+    stats     ConnStats
+
+    sessionDirty bool // session settings changed since the last reset
+    moneyDigits  int  // fraction digits of the session currency, for MoneyDecimal
+    txMeta       map[string]string // query metadata set in the current transaction
+    replica      bool              // the server was in recovery when connected
+    // domains maps type OIDs looked up by resolveDomains to the domain
+    // name, or to "" for types that are not domains over a known type.
+    domains map[uint32]string
+    // onNotice receives the notices of the running statement.
+    onNotice func(n *Notice)
+    enums    bool // RegisterEnum types are registered, so parameters are checked
+    describe *describeTracer
+    // running counts the statements in progress, for CancelAll to skip idle
+    // connections.
+    running atomic.Int32
 }
 
 func (c *serinConn) Prepare(query string) (driver.Stmt, error) {
-    return &serinStmt{conn: c.conn, query: query}, nil
+    return c.PrepareContext(context.Background(), query)
+}
+
+func (c *serinConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+    if isTxControl(query) {
+        return nil, ErrRawTxControl
+    }
+    return &serinStmt{conn: c, query: query}, nil
+}
+
+func (c *serinConn) Close() error {
+    c.connector.release(c)
+    return c.conn.Close(context.Background())
+}
+
+// ResetSession clears settings changed by the previous user and restores the
+// connector's session settings before the connection is handed to its next
+// user. A connection whose role cannot be restored is discarded rather than
+// reused as the login role; database/sql ignores any error but ErrBadConn,
+// so the cause is logged.
+func (c *serinConn) ResetSession(ctx context.Context) error {
+    if c.conn.IsClosed() {
+        return driver.ErrBadConn
+    }
+    c.onNotice = nil
+    if err := c.resetSession(ctx); err != nil {
+        return driver.ErrBadConn
+    }
+    if err := c.connector.applyRole(ctx, c); err != nil {
+        c.connector.log().WarnContext(ctx, "serin: discarding connection", slog.String("error", err.Error()))
+        return driver.ErrBadConn
+    }
+    return nil
+}
+
+// IsValid keeps connections that lost their server out of the pool.
+func (c *serinConn) IsValid() bool { return !c.conn.IsClosed() }
+
+func (c *serinConn) Ping(ctx context.Context) error {
+    if err := c.conn.Ping(ctx); err != nil {
+        return driver.ErrBadConn
+    }
+    return nil
+}
+
+func (c *serinConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+    return c.exec(ctx, query, namedArgs(args))
+}
+
+func (c *serinConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+    return c.query(ctx, query, namedArgs(args))
 }
 
-func (c *serinConn) Close() error { return c.conn.Close(context.Background()) }
+func (c *serinConn) exec(ctx context.Context, query string, args []any) (driver.Result, error) {
+    c.running.Add(1)
+    defer c.running.Add(-1)
+    start := time.Now()
+    defer c.observe(ctx, start, query, args)
+    name, pgArgs, err := c.prepare(ctx, query, args)
+    if err != nil {
+        return nil, err
+    }
+    ct, err := c.conn.Exec(ctx, name, pgArgs...)
+    if err != nil && c.describe.refused && c.simpleFallback(err) {
+        ct, err = c.conn.Exec(ctx, name, forceSimple(pgArgs)...)
+    }
+    if retry, ok := c.reprepare(ctx, err, name); ok {
+        ct, err = c.conn.Exec(ctx, retry, pgArgs...)
+    }
+    if err != nil {
+        return nil, c.queryFailed(ctx, query, err)
+    }
+    return commandResult{tag: ct}, nil
+}
+
+func (c *serinConn) query(ctx context.Context, query string, args []any) (driver.Rows, error) {
+    c.running.Add(1)
+    start := time.Now()
+    name, pgArgs, err := c.prepare(ctx, query, args)
+    if err != nil {
+        c.running.Add(-1)
+        c.observe(ctx, start, query, args)
+        return nil, err
+    }
+    rows, err := c.conn.Query(ctx, name, pgArgs...)
+    if err != nil && c.describe.refused && c.simpleFallback(err) {
+        rows, err = c.conn.Query(ctx, name, forceSimple(pgArgs)...)
+    }
+    if err != nil {
+        c.running.Add(-1)
+        c.observe(ctx, start, query, args)
+        return nil, c.queryFailed(ctx, query, err)
+    }
+    sr := &serinRows{pgRows: rows, conn: c, ctx: ctx, stmt: name, pgArgs: pgArgs, start: start, query: query, args: args}
+    if n := c.connector.bufferRows; n > 0 {
+        sr.prefetch = newRowBuffer(n, len(rows.FieldDescriptions()))
+    }
+    return sr, nil
+}
+
+// prepare validates and rewrites query for execution, returning the SQL or
+// statement name to hand to pgx along with the final arguments.
+func (c *serinConn) prepare(ctx context.Context, query string, args []any) (string, []any, error) {
+    c.used()
+    c.onNotice = noticeHandler(ctx)
+    if isSessionSet(query) {
+        c.sessionDirty = true
+    }
+    if isTxControl(query) {
+        return "", nil, ErrRawTxControl
+    }
+    if err := c.checkWrite(query); err != nil {
+        return "", nil, err
+    }
+    if err := c.applyMeta(ctx); err != nil {
+        return "", nil, err
+    }
+    args, simple := simpleMode(args)
+    query, args, err := expandIn(query, args)
+    if err != nil {
+        return "", nil, err
+    }
+    tagged := false
+    if c.connector.commenter != nil {
+        if tags := c.connector.commenter(ctx); len(tags) > 0 {
+            query, tagged = sqlComment(query, tags), true
+        }
+    }
+    if c.connector.emptyAsNull {
+        nullEmptyStrings(args)
+    }
+    if c.connector.strictTime {
+        if err := checkTimePrecision(args); err != nil {
+            return "", nil, err
+        }
+    }
+    if simple {
+        return query, forceSimple(args), nil
+    }
+    // Tags such as trace ids differ per request, so tagged statements are
+    // sent unnamed rather than filling the statement cache.
+    name := query
+    if !tagged {
+        if name, err = c.statement(ctx, query); err != nil {
+            if c.simpleFallback(err) {
+                return query, forceSimple(args), nil
+            }
+            return "", nil, c.failed(ctx, err)
+        }
+    }
+    if len(args) > 0 && (name != query || hasArrayArg(args) || c.enums) {
+        if args, err = c.encodeParams(ctx, name, query, args); err != nil {
+            return "", nil, c.failed(ctx, err)
+        }
+    }
+    return name, args, nil
+}
 
-func (c *serinConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+// failed records err in the connector metrics and returns it unchanged.
+func (c *serinConn) failed(ctx context.Context, err error) error {
+    c.connector.metrics.recordError(classifyError(ctx, err, c.conn.IsClosed()))
+    return err
+}
 
 // serinStmt implements driver.Stmt
 
 type serinStmt struct {
-    conn  *pgx.Conn
+    conn  *serinConn
     query string
 }
 
@@ -50,37 +233,201 @@ func (s *serinStmt) Close() error { return nil }
 func (s *serinStmt) NumInput() int { return -1 }
 
 func (s *serinStmt) Exec(args []driver.Value) (driver.Result, error) {
-    ct, err := s.conn.Exec(context.Background(), s.query).RowsAffected(), error(nil)
-    return driver.RowsAffected(ct), err
+    return s.conn.exec(context.Background(), s.query, toArgs(args))
 }
 
 func (s *serinStmt) Query(args []driver.Value) (driver.Rows, error) {
-    rows, err := s.conn.Query(context.Background(), s.query)
-    if err != nil {
-        return nil, err
-    }
-    return &serinRows{pgRows: rows}, nil
+    return s.conn.query(context.Background(), s.query, toArgs(args))
+}
+
+func (s *serinStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+    return s.conn.exec(ctx, s.query, namedArgs(args))
+}
+
+func (s *serinStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+    return s.conn.query(ctx, s.query, namedArgs(args))
 }
 
 // serinRows wraps pgx.Rows to implement driver.Rows
 
 type serinRows struct {
     pgRows pgx.Rows
+    conn   *serinConn
+    ctx    context.Context
+    failed bool   // the error was already recorded by Next
+    arrays []bool // columns returned as text array literals, resolved on first row
+    prefetch *rowBuffer // nil unless result_buffer_rows is set
+
+    // stmt and pgArgs are what pgx ran, to retry a re-prepared statement.
+    stmt    string
+    pgArgs  []any
+    begun   bool // the first row was fetched
+    peeked  bool // the first row was fetched but not yet returned
+
+    // seenRows and seenBytes count the result against WithResultLimit.
+    seenRows  int64
+    seenBytes int64
+
+    // start, query and args describe the query for the slow query log.
+    start time.Time
+    query string
+    args  []any
 }
 
 func (r *serinRows) Columns() []string {
+    r.begin()
     flds := r.pgRows.FieldDescriptions()
     cols := make([]string, len(flds))
     for i, f := range flds { cols[i] = string(f.Name) }
     return cols
 }
 
-func (r *serinRows) Close() error { r.pgRows.Close(); return nil }
+// CommandTagger is implemented by the driver.Rows and driver.Result of this
+// driver. CommandTag returns the server's command tag (such as "INSERT 0 3"
+// for INSERT ... RETURNING, or "CREATE INDEX" for a utility statement that
+// affects no rows) and the affected row count; for rows, once the result set
+// has been fully iterated or closed. database/sql wraps the rows and results
+// it returns, so reach the driver's through sql.Conn.Raw and
+// driver.QueryerContext or driver.ExecerContext.
+type CommandTagger interface {
+    CommandTag() (tag string, rowsAffected int64)
+}
+
+// commandResult is the driver.Result of Exec.
+type commandResult struct{ tag pgconn.CommandTag }
+
+func (r commandResult) LastInsertId() (int64, error) { return driver.RowsAffected(0).LastInsertId() }
+
+func (r commandResult) RowsAffected() (int64, error) { return r.tag.RowsAffected(), nil }
+
+func (r commandResult) CommandTag() (string, int64) { return r.tag.String(), r.tag.RowsAffected() }
+
+func (r *serinRows) CommandTag() (string, int64) {
+    tag := r.pgRows.CommandTag()
+    return tag.String(), tag.RowsAffected()
+}
+
+func (r *serinRows) Close() error {
+    r.pgRows.Close()
+    if err := r.pgRows.Err(); err != nil && !r.failed {
+        r.conn.failed(r.ctx, err)
+    }
+    r.conn.running.Add(-1)
+    r.conn.observe(r.ctx, r.start, r.query, r.args)
+    return nil
+}
 
 func (r *serinRows) Next(dest []driver.Value) error {
-    if !r.pgRows.Next() { return driver.ErrBadConn }
+    if r.prefetch != nil {
+        return r.nextBuffered(dest)
+    }
+    return r.read(dest)
+}
+
+// begin fetches the first row before the columns are reported, so that a
+// statement invalidated by a schema change can be re-prepared and run again
+// while its new columns can still be described.
+func (r *serinRows) begin() {
+    if r.begun {
+        return
+    }
+    r.begun = true
+    r.peeked = r.pgRows.Next()
+    if r.peeked {
+        return
+    }
+    retry, ok := r.conn.reprepare(r.ctx, r.pgRows.Err(), r.stmt)
+    if !ok {
+        return
+    }
+    rows, err := r.conn.conn.Query(r.ctx, retry, r.pgArgs...)
+    if err != nil {
+        return
+    }
+    r.pgRows.Close()
+    r.pgRows, r.stmt = rows, retry
+    r.peeked = r.pgRows.Next()
+}
+
+// next advances pgx to the next row, starting with the one begin fetched.
+func (r *serinRows) next() bool {
+    r.begin()
+    if r.peeked {
+        r.peeked = false
+        return true
+    }
+    return r.pgRows.Next()
+}
+
+// read decodes the next row from pgx into dest.
+func (r *serinRows) read(dest []driver.Value) error {
+    if !r.next() {
+        if err := r.pgRows.Err(); err != nil {
+            r.failed = true
+            return r.conn.queryFailed(r.ctx, r.query, err)
+        }
+        return io.EOF
+    }
+    if err := r.checkLimit(); err != nil {
+        return err
+    }
     values, err := r.pgRows.Values()
     if err != nil { return err }
-    copy(dest, values)
+    m := r.conn.conn.TypeMap()
+    flds := r.pgRows.FieldDescriptions()
+    if r.arrays == nil {
+        oids := make([]uint32, len(flds))
+        for i, f := range flds { oids[i] = f.DataTypeOID }
+        r.arrays = arrayColumns(m, oids)
+    }
+    raw := r.pgRows.RawValues()
+    for i := range dest {
+        switch {
+        case r.arrays[i] && raw[i] != nil:
+            if dest[i], err = arrayText(m, flds[i].DataTypeOID, flds[i].Format, raw[i]); err != nil {
+                return err
+            }
+        case flds[i].DataTypeOID == moneyOID:
+            dest[i] = r.conn.moneyValue(values[i])
+        case flds[i].DataTypeOID == pgtype.JSONOID || flds[i].DataTypeOID == pgtype.JSONBOID:
+            if dest[i], err = jsonText(m, flds[i].DataTypeOID, flds[i].Format, raw[i]); err != nil {
+                return err
+            }
+        default:
+            dest[i] = sqlValue(values[i])
+        }
+    }
     return nil
-} 
\ No newline at end of file
+}
+
+// toArgs converts database/sql driver values into pgx query arguments.
+func toArgs(args []driver.Value) []any {
+    out := make([]any, len(args))
+    for i, a := range args { out[i] = a }
+    return out
+}
+
+// namedArgs converts ordinal database/sql arguments into pgx query arguments.
+func namedArgs(args []driver.NamedValue) []any {
+    out := make([]any, len(args))
+    for i, a := range args { out[i] = a.Value }
+    return out
+}
+
+// withConn runs fn against the pgx connection backing one pooled connection of db.
+func withConn(ctx context.Context, db *sql.DB, fn func(c *serinConn) error) error {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    return conn.Raw(func(dc any) error {
+        c, ok := dc.(*serinConn)
+        if !ok {
+            return errors.New("serin: connection was not opened by the serin driver")
+        }
+        c.running.Add(1)
+        defer c.running.Add(-1)
+        return fn(c)
+    })
+}
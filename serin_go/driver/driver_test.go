@@ -0,0 +1,132 @@
+package driver
+
+import (
+    "context"
+    "database/sql/driver"
+    "io"
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRowsCommandTag(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_returning", "CREATE TABLE serin_returning (id serial, name text)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_returning") })
+    ctx := context.Background()
+
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    err = conn.Raw(func(dc any) error {
+        rows, err := dc.(driver.QueryerContext).QueryContext(ctx, "INSERT INTO serin_returning (name) VALUES ('a'), ('b'), ('c') RETURNING id", nil)
+        if err != nil {
+            return err
+        }
+        var ids []any
+        dest := make([]driver.Value, 1)
+        for {
+            if err := rows.Next(dest); err == io.EOF {
+                break
+            } else if err != nil {
+                return err
+            }
+            ids = append(ids, dest[0])
+        }
+        rows.Close()
+        tag, n := rows.(CommandTagger).CommandTag()
+        if len(ids) != 3 || tag != "INSERT 0 3" || n != 3 {
+            t.Errorf("got ids %v, tag %q, affected %d", ids, tag, n)
+        }
+        return nil
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestCommandResult(t *testing.T) {
+    r := commandResult{tag: pgconn.NewCommandTag("UPDATE 4")}
+    if n, err := r.RowsAffected(); n != 4 || err != nil {
+        t.Errorf("RowsAffected = %d, %v", n, err)
+    }
+    if _, err := r.LastInsertId(); err == nil {
+        t.Error("LastInsertId succeeded")
+    }
+    if tag, n := r.CommandTag(); tag != "UPDATE 4" || n != 4 {
+        t.Errorf("CommandTag = %q, %d", tag, n)
+    }
+}
+
+func TestExecCommandTag(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_tags")
+    t.Cleanup(func() { db.Exec("DROP TABLE IF EXISTS serin_tags") })
+    ctx := context.Background()
+
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    for _, tc := range []struct{ query, tag string }{
+        {"CREATE TABLE serin_tags (id int)", "CREATE TABLE"},
+        {"INSERT INTO serin_tags SELECT generate_series(1, 5)", "INSERT 0 5"},
+        {"CREATE INDEX serin_tags_id ON serin_tags (id)", "CREATE INDEX"},
+        {"ANALYZE serin_tags", "ANALYZE"},
+        {"COMMENT ON TABLE serin_tags IS 'tags'", "COMMENT"},
+        {"DROP TABLE serin_tags", "DROP TABLE"},
+    } {
+        err := conn.Raw(func(dc any) error {
+            res, err := dc.(driver.ExecerContext).ExecContext(ctx, tc.query, nil)
+            if err != nil {
+                return err
+            }
+            if tag, _ := res.(CommandTagger).CommandTag(); tag != tc.tag {
+                t.Errorf("%s: tag %q, want %q", tc.query, tag, tc.tag)
+            }
+            return nil
+        })
+        if err != nil {
+            t.Fatalf("%s: %v", tc.query, err)
+        }
+    }
+}
+
+func TestConnStats(t *testing.T) {
+    db := testDB(t)
+    ctx := context.Background()
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    stats := func() ConnStats {
+        var st ConnStats
+        conn.Raw(func(dc any) error { st = dc.(StatsReporter).Stats(); return nil })
+        return st
+    }
+
+    before := stats()
+    if before.Established.IsZero() {
+        t.Fatal("established time not recorded")
+    }
+    for i := 0; i < 3; i++ {
+        if _, err := conn.ExecContext(ctx, "SELECT 1"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    var n int
+    if err := conn.QueryRowContext(ctx, "SELECT 1").Scan(&n); err != nil {
+        t.Fatal(err)
+    }
+    after := stats()
+    if after.Queries != before.Queries+4 {
+        t.Errorf("queries %d -> %d, want +4", before.Queries, after.Queries)
+    }
+    if !after.LastUsed.After(before.LastUsed) || !after.Established.Equal(before.Established) {
+        t.Errorf("unexpected stats %+v -> %+v", before, after)
+    }
+}
@@ -0,0 +1,22 @@
+package driver
+
+// WithEmptyStringAsNull makes every empty string argument bind as NULL, for
+// legacy schemas that store missing text as either. It applies to all
+// parameters, so a WHERE name = $1 bound to "" matches no rows and an empty
+// string written to a NOT NULL column fails. It only changes what is written:
+// scanning still returns "" for empty strings and NULL for NULLs, so read such
+// columns into sql.NullString, or compare them with an empty default as before:
+//
+//	WHERE coalesce(col, '') = $1
+func WithEmptyStringAsNull(enabled bool) Option {
+    return func(c *Connector) { c.emptyAsNull = enabled }
+}
+
+// nullEmptyStrings replaces the empty string arguments of args with nil.
+func nullEmptyStrings(args []any) {
+    for i, a := range args {
+        if s, ok := a.(string); ok && s == "" {
+            args[i] = nil
+        }
+    }
+}
@@ -0,0 +1,47 @@
+package driver
+
+import (
+    "database/sql"
+    "reflect"
+    "testing"
+)
+
+func TestNullEmptyStrings(t *testing.T) {
+    args := []any{"", "x", nil, []byte{}, 0, []string{""}}
+    nullEmptyStrings(args)
+    if want := []any{nil, "x", nil, []byte{}, 0, []string{""}}; !reflect.DeepEqual(args, want) {
+        t.Errorf("got %#v", args)
+    }
+}
+
+func TestEmptyStringAsNull(t *testing.T) {
+    setup := testDB(t)
+    mustExec(t, setup, "DROP TABLE IF EXISTS serin_empty", "CREATE TABLE serin_empty (id int, note text)")
+    t.Cleanup(func() { setup.Exec("DROP TABLE serin_empty") })
+    empty := ""
+    for _, enabled := range []bool{false, true} {
+        db, _ := testConnectorDB(t, WithEmptyStringAsNull(enabled))
+        mustExec(t, db, "TRUNCATE serin_empty")
+        for i, v := range []any{"", &empty, sql.NullString{Valid: true}, "x"} {
+            if _, err := db.Exec("INSERT INTO serin_empty VALUES ($1, $2)", i, v); err != nil {
+                t.Fatal(err)
+            }
+        }
+        var nulls, empties int
+        if err := db.QueryRow("SELECT count(*) FILTER (WHERE note IS NULL), count(*) FILTER (WHERE note = '') FROM serin_empty").Scan(&nulls, &empties); err != nil {
+            t.Fatal(err)
+        }
+        want := [2]int{0, 3}
+        if enabled {
+            want = [2]int{3, 0}
+        }
+        if got := [2]int{nulls, empties}; got != want {
+            t.Errorf("enabled %v: %d NULLs and %d empty strings, want %v", enabled, nulls, empties, want)
+        }
+        // Reads are unaffected.
+        var s sql.NullString
+        if err := db.QueryRow("SELECT ''::text").Scan(&s); err != nil || s != (sql.NullString{Valid: true}) {
+            t.Errorf("enabled %v: read %#v, %v", enabled, s, err)
+        }
+    }
+}
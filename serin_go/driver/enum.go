@@ -0,0 +1,124 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidEnumValue is wrapped by the error of binding a value that is not
+// one of the values registered with RegisterEnum.
+var ErrInvalidEnumValue = errors.New("serin: invalid enum value")
+
+// RegisterEnum registers the enum type typeName, optionally schema
+// qualified, with the values the application accepts, usually the values of
+// its Go constants:
+//
+//	type Color string
+//	const (Red Color = "red"; Green Color = "green")
+//	c.RegisterEnum("color", []string{string(Red), string(Green)})
+//
+// Binding a value of the enum, or of an array of it, that is not in values
+// then fails with ErrInvalidEnumValue before the statement is sent, naming
+// the value and the type. This applies wherever the driver knows the
+// parameter's type, that is to every statement except those sent with
+// SimpleProtocol, which the server still checks. Columns of the type scan into
+// strings and string-kind types such as Color as usual, including values
+// added to the enum later.
+//
+// The type is looked up on every new connection, which fails if it does not
+// exist, so call RegisterEnum before the first connection is opened.
+func (c *Connector) RegisterEnum(typeName string, values []string) {
+    allowed := make(map[string]bool, len(values))
+    for _, v := range values {
+        allowed[v] = true
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.enums == nil {
+        c.enums = make(map[string]map[string]bool)
+    }
+    c.enums[typeName] = allowed
+}
+
+// registerEnums registers the codecs of the RegisterEnum types on sc.
+func (c *Connector) registerEnums(ctx context.Context, sc *serinConn) error {
+    c.mu.Lock()
+    enums := c.enums
+    c.mu.Unlock()
+    m := sc.conn.TypeMap()
+    sc.enums = len(enums) > 0
+    for name, allowed := range enums {
+        var oid, array uint32
+        err := sc.conn.QueryRow(ctx, "SELECT oid, typarray FROM pg_type WHERE oid = to_regtype($1) AND typtype = 'e'", pgx.QueryExecModeSimpleProtocol, name).Scan(&oid, &array)
+        if errors.Is(err, pgx.ErrNoRows) {
+            return fmt.Errorf("serin: enum type %s does not exist", name)
+        }
+        if err != nil {
+            return fmt.Errorf("serin: looking up enum %s: %w", name, err)
+        }
+        enum := &pgtype.Type{Name: name, OID: oid, Codec: &enumCodec{EnumCodec: &pgtype.EnumCodec{}, name: name, allowed: allowed}}
+        m.RegisterType(enum)
+        if array != 0 {
+            m.RegisterType(&pgtype.Type{Name: "_" + name, OID: array, Codec: &pgtype.ArrayCodec{ElementType: enum}})
+        }
+    }
+    return nil
+}
+
+// checkEnum returns ErrInvalidEnumValue when a, bound to a parameter of type
+// oid, is not among the RegisterEnum values of that enum or, for an array,
+// of its element type. pgx sends strings as text without consulting any codec,
+// so the value is test-encoded in the binary format, which goes through
+// enumCodec.
+func checkEnum(m *pgtype.Map, oid uint32, a any) error {
+    t, ok := m.TypeForOID(oid)
+    if a == nil || !ok {
+        return nil
+    }
+    if array, ok := t.Codec.(*pgtype.ArrayCodec); ok {
+        t = array.ElementType
+    }
+    if _, ok := t.Codec.(*enumCodec); !ok {
+        return nil
+    }
+    if _, err := m.Encode(oid, pgtype.BinaryFormatCode, a, nil); errors.Is(err, ErrInvalidEnumValue) {
+        return err
+    }
+    return nil
+}
+
+// enumCodec is pgtype.EnumCodec rejecting values outside allowed on encode.
+type enumCodec struct {
+    *pgtype.EnumCodec
+    name    string
+    allowed map[string]bool
+}
+
+func (c *enumCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+    plan := c.EnumCodec.PlanEncode(m, oid, format, value)
+    if plan == nil {
+        return nil
+    }
+    return enumEncodePlan{next: plan, codec: c}
+}
+
+type enumEncodePlan struct {
+    next  pgtype.EncodePlan
+    codec *enumCodec
+}
+
+// Encode checks the label, which is the same in the text and binary formats.
+func (p enumEncodePlan) Encode(value any, buf []byte) ([]byte, error) {
+    out, err := p.next.Encode(value, buf)
+    if err != nil || out == nil {
+        return out, err
+    }
+    if label := string(out[len(buf):]); !p.codec.allowed[label] {
+        return nil, fmt.Errorf("%w: %q is not a value of %s", ErrInvalidEnumValue, label, p.codec.name)
+    }
+    return out, nil
+}
@@ -0,0 +1,88 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+type testColor string
+
+const (
+    testRed   testColor = "red"
+    testGreen testColor = "green"
+)
+
+func TestCheckEnum(t *testing.T) {
+    m := pgtype.NewMap()
+    enum := &pgtype.Type{Name: "color", OID: 90001, Codec: &enumCodec{EnumCodec: &pgtype.EnumCodec{}, name: "color", allowed: map[string]bool{"red": true, "green": true}}}
+    m.RegisterType(enum)
+    m.RegisterType(&pgtype.Type{Name: "_color", OID: 90002, Codec: &pgtype.ArrayCodec{ElementType: enum}})
+
+    for _, tc := range []struct {
+        oid uint32
+        arg any
+        ok  bool
+    }{
+        {90001, "red", true},
+        {90001, testGreen, true},
+        {90001, nil, true},
+        {90001, "blue", false},
+        {90001, testColor("blue"), false},
+        {90002, []string{"green", "red"}, true},
+        {90002, []string{"green", "purple"}, false},
+        {pgtype.TextOID, "blue", true},
+    } {
+        err := checkEnum(m, tc.oid, tc.arg)
+        if tc.ok && err != nil || !tc.ok && !errors.Is(err, ErrInvalidEnumValue) {
+            t.Errorf("OID %d, %#v: got %v", tc.oid, tc.arg, err)
+        }
+    }
+    if buf, err := m.Encode(90001, pgtype.TextFormatCode, testRed, []byte("x")); err != nil || string(buf) != "xred" {
+        t.Errorf("red encoded as %q, %v", buf, err)
+    }
+}
+
+func TestRegisterEnum(t *testing.T) {
+    setup := testDB(t)
+    mustExec(t, setup, "DROP TABLE IF EXISTS serin_enum", "DROP TYPE IF EXISTS serin_color",
+        "CREATE TYPE serin_color AS ENUM ('red', 'green', 'blue')", "CREATE TABLE serin_enum (id int, c serin_color, cs serin_color[])")
+    t.Cleanup(func() { setup.Exec("DROP TABLE serin_enum"); setup.Exec("DROP TYPE serin_color") })
+    db, c := testConnectorDB(t)
+    // blue exists on the server but the application does not accept it.
+    c.RegisterEnum("serin_color", []string{string(testRed), string(testGreen)})
+
+    if _, err := db.Exec("INSERT INTO serin_enum VALUES (1, $1, $2)", testGreen, []string{"red", "green"}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := db.Exec("INSERT INTO serin_enum VALUES (2, $1, NULL)", "blue"); !errors.Is(err, ErrInvalidEnumValue) {
+        t.Errorf("blue: got %v, want ErrInvalidEnumValue", err)
+    }
+    if _, err := db.Exec("INSERT INTO serin_enum VALUES (3, NULL, $1)", []string{"red", "purple"}); !errors.Is(err, ErrInvalidEnumValue) {
+        t.Errorf("purple in array: got %v, want ErrInvalidEnumValue", err)
+    }
+
+    var got testColor
+    var missing sql.NullString
+    if err := db.QueryRow("SELECT c, (SELECT c FROM serin_enum WHERE id = 2) FROM serin_enum WHERE id = 1").Scan(&got, &missing); err != nil {
+        t.Fatal(err)
+    }
+    if got != testGreen || missing.Valid {
+        t.Errorf("got %q, %v", got, missing)
+    }
+    native, err := ScanValue[testColor](context.Background(), db, "SELECT 'blue'::serin_color")
+    if err != nil || native != "blue" {
+        t.Errorf("ScanValue got %q, %v", native, err)
+    }
+}
+
+func TestRegisterEnumMissingType(t *testing.T) {
+    db, c := testConnectorDB(t)
+    c.RegisterEnum("serin_no_such_enum", []string{"a"})
+    if err := db.Ping(); err == nil {
+        t.Error("connected without the registered enum type")
+    }
+}
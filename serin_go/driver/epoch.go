@@ -0,0 +1,59 @@
+package driver
+
+import (
+    "database/sql/driver"
+    "fmt"
+    "time"
+)
+
+// EpochMillis is a timestamp expressed as milliseconds since the Unix epoch
+// (1970-01-01 00:00:00 UTC). It scans from timestamptz and timestamp columns
+// and binds as a UTC time.Time, so timestamp (without time zone) columns store
+// the UTC wall clock. Scan into an existing int64 with (*EpochMillis)(&v).
+//
+// A NULL column cannot be represented as EpochMillis; scan into a
+// *EpochMillis, which is left nil, when the column is nullable.
+type EpochMillis int64
+
+// EpochMicros is EpochMillis with microsecond units, matching the server's
+// timestamp resolution exactly.
+type EpochMicros int64
+
+// Time returns e as a UTC time.Time.
+func (e EpochMillis) Time() time.Time { return time.UnixMilli(int64(e)).UTC() }
+
+// Time returns e as a UTC time.Time.
+func (e EpochMicros) Time() time.Time { return time.UnixMicro(int64(e)).UTC() }
+
+func (e EpochMillis) Value() (driver.Value, error) { return e.Time(), nil }
+
+func (e EpochMicros) Value() (driver.Value, error) { return e.Time(), nil }
+
+func (e *EpochMillis) Scan(src any) error {
+    t, err := scanEpochTime(src, "EpochMillis")
+    if err != nil {
+        return err
+    }
+    *e = EpochMillis(t.UnixMilli())
+    return nil
+}
+
+func (e *EpochMicros) Scan(src any) error {
+    t, err := scanEpochTime(src, "EpochMicros")
+    if err != nil {
+        return err
+    }
+    *e = EpochMicros(t.UnixMicro())
+    return nil
+}
+
+func scanEpochTime(src any, name string) (time.Time, error) {
+    switch v := src.(type) {
+    case time.Time:
+        return v, nil
+    case nil:
+        return time.Time{}, fmt.Errorf("serin: cannot scan NULL into %s; scan into *%s instead", name, name)
+    default:
+        return time.Time{}, fmt.Errorf("serin: cannot scan %T into %s", src, name)
+    }
+}
@@ -0,0 +1,95 @@
+package driver
+
+import (
+    "context"
+    "testing"
+    "time"
+)
+
+func TestEpochScanAcrossZones(t *testing.T) {
+    instant := time.Date(2024, 3, 10, 7, 30, 15, 123456000, time.UTC)
+    for _, zone := range []string{"UTC", "America/New_York", "Asia/Tokyo", "Australia/Lord_Howe"} {
+        loc, err := time.LoadLocation(zone)
+        if err != nil {
+            t.Skipf("tzdata unavailable: %v", err)
+        }
+        var ms EpochMillis
+        var us EpochMicros
+        if err := ms.Scan(instant.In(loc)); err != nil {
+            t.Fatal(err)
+        }
+        if err := us.Scan(instant.In(loc)); err != nil {
+            t.Fatal(err)
+        }
+        if int64(ms) != 1710055815123 {
+            t.Errorf("%s: millis = %d", zone, ms)
+        }
+        if int64(us) != 1710055815123456 {
+            t.Errorf("%s: micros = %d", zone, us)
+        }
+    }
+}
+
+func TestEpochValueRoundTrip(t *testing.T) {
+    v, err := EpochMicros(1710055815123456).Value()
+    if err != nil {
+        t.Fatal(err)
+    }
+    var back EpochMicros
+    if err := back.Scan(v); err != nil {
+        t.Fatal(err)
+    }
+    if back != 1710055815123456 {
+        t.Errorf("round trip gave %d", back)
+    }
+    if EpochMillis(0).Time() != time.Unix(0, 0).UTC() {
+        t.Error("zero EpochMillis is not the Unix epoch")
+    }
+}
+
+func TestEpochScanNull(t *testing.T) {
+    var ms EpochMillis
+    if err := ms.Scan(nil); err == nil {
+        t.Error("scanning NULL into EpochMillis succeeded")
+    }
+}
+
+func TestEpochRoundTripServer(t *testing.T) {
+    db := testDB(t)
+    db.SetMaxOpenConns(1)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_epoch", "CREATE TABLE serin_epoch (id int, tz timestamptz, ts timestamp)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_epoch") })
+
+    const ms = EpochMillis(1710055815123)
+    if _, err := db.Exec("INSERT INTO serin_epoch VALUES (1, $1, $2), (2, NULL, NULL)", ms, ms); err != nil {
+        t.Fatal(err)
+    }
+    // SET only lasts until the connection returns to the pool, so the zone
+    // and the query share one pinned connection.
+    ctx := context.Background()
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    for _, zone := range []string{"UTC", "America/New_York", "Asia/Kolkata"} {
+        if _, err := conn.ExecContext(ctx, "SET TIME ZONE '"+zone+"'"); err != nil {
+            t.Fatal(err)
+        }
+        var current string
+        var tz, ts EpochMillis
+        if err := conn.QueryRowContext(ctx, "SELECT current_setting('TimeZone'), tz, ts FROM serin_epoch WHERE id = 1").Scan(&current, &tz, &ts); err != nil {
+            t.Fatal(err)
+        }
+        if current != zone || tz != ms || ts != ms {
+            t.Errorf("%s: got zone %s, tz=%d ts=%d, want %d", zone, current, tz, ts, ms)
+        }
+    }
+    var null *EpochMicros
+    if err := db.QueryRow("SELECT tz FROM serin_epoch WHERE id = 2").Scan(&null); err != nil {
+        t.Fatal(err)
+    }
+    if null != nil {
+        t.Errorf("NULL scanned as %d", *null)
+    }
+}
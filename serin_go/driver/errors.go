@@ -0,0 +1,71 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net"
+    "strings"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrorKind buckets query failures for the metrics collector.
+type ErrorKind int
+
+const (
+    ErrorOther      ErrorKind = iota // client side failures such as encoding errors
+    ErrorTimeout                     // context deadline or server statement/lock timeout
+    ErrorCanceled                    // context cancellation or an explicit cancel request
+    ErrorConnection                  // the connection broke or the server is shutting down
+    ErrorSQL                         // any other error reported by the server
+    ErrorDeadlock                    // the server aborted the transaction to break a deadlock
+)
+
+func (k ErrorKind) String() string {
+    switch k {
+    case ErrorTimeout:
+        return "timeout"
+    case ErrorCanceled:
+        return "canceled"
+    case ErrorConnection:
+        return "connection"
+    case ErrorSQL:
+        return "sql"
+    case ErrorDeadlock:
+        return "deadlock"
+    }
+    return "other"
+}
+
+// classifyError buckets err from a query run under ctx. closed reports whether
+// the connection was lost while running it.
+func classifyError(ctx context.Context, err error, closed bool) ErrorKind {
+    switch {
+    case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+        return ErrorTimeout
+    case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+        return ErrorCanceled
+    }
+    var pgErr *pgconn.PgError
+    if errors.As(err, &pgErr) {
+        switch {
+        case pgErr.Code == "57014" && strings.Contains(pgErr.Message, "timeout"):
+            return ErrorTimeout
+        case pgErr.Code == "57014":
+            return ErrorCanceled
+        case pgErr.Code == "40P01":
+            return ErrorDeadlock
+        case pgErr.Code == "55P03" && strings.Contains(pgErr.Message, "lock timeout"):
+            return ErrorTimeout
+        case strings.HasPrefix(pgErr.Code, "08"), pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03":
+            return ErrorConnection
+        }
+        return ErrorSQL
+    }
+    var netErr net.Error
+    if closed || errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+        return ErrorConnection
+    }
+    return ErrorOther
+}
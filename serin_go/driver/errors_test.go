@@ -0,0 +1,84 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassifyError(t *testing.T) {
+    bg := context.Background()
+    expired, cancel := context.WithDeadline(bg, time.Now().Add(-time.Second))
+    defer cancel()
+    canceled, cancel := context.WithCancel(bg)
+    cancel()
+
+    for _, tc := range []struct {
+        ctx    context.Context
+        err    error
+        closed bool
+        want   ErrorKind
+    }{
+        {bg, context.DeadlineExceeded, false, ErrorTimeout},
+        {expired, errors.New("timeout: context already done"), false, ErrorTimeout},
+        {bg, &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"}, false, ErrorTimeout},
+        {bg, &pgconn.PgError{Code: "55P03", Message: "canceling statement due to lock timeout"}, false, ErrorTimeout},
+        {canceled, errors.New("closed"), false, ErrorCanceled},
+        {bg, &pgconn.PgError{Code: "57014", Message: "canceling statement due to user request"}, false, ErrorCanceled},
+        {bg, &pgconn.PgError{Code: "57P01", Message: "terminating connection due to administrator command"}, true, ErrorConnection},
+        {bg, &pgconn.PgError{Code: "08006"}, false, ErrorConnection},
+        {bg, &net.OpError{Op: "read", Err: errors.New("reset")}, false, ErrorConnection},
+        {bg, io.ErrUnexpectedEOF, false, ErrorConnection},
+        {bg, errors.New("conn closed"), true, ErrorConnection},
+        {bg, &pgconn.PgError{Code: "42P01"}, false, ErrorSQL},
+        {bg, &pgconn.PgError{Code: "40P01"}, false, ErrorDeadlock},
+        {bg, &pgconn.PgError{Code: "55P03", Message: "could not obtain lock on row"}, false, ErrorSQL},
+        {bg, errors.New("unable to encode"), false, ErrorOther},
+    } {
+        if got := classifyError(tc.ctx, tc.err, tc.closed); got != tc.want {
+            t.Errorf("classifyError(%v) = %s, want %s", tc.err, got, tc.want)
+        }
+    }
+}
+
+func TestErrorMetricsBuckets(t *testing.T) {
+    db, c := testConnectorDB(t)
+    bg := context.Background()
+
+    ctx, cancel := context.WithTimeout(bg, 50*time.Millisecond)
+    _, err := db.ExecContext(ctx, "SELECT pg_sleep(5)")
+    cancel()
+    if err == nil {
+        t.Fatal("expected timeout")
+    }
+
+    ctx, cancel = context.WithCancel(bg)
+    time.AfterFunc(50*time.Millisecond, cancel)
+    _, err = db.ExecContext(ctx, "SELECT pg_sleep(5)")
+    if err == nil {
+        t.Fatal("expected cancellation")
+    }
+
+    if _, err := db.QueryContext(bg, "SELECT * FROM serin_no_such_table"); err == nil {
+        t.Fatal("expected SQL error")
+    }
+
+    conn, err := db.Conn(bg)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := conn.ExecContext(bg, "SELECT pg_terminate_backend(pg_backend_pid())"); err == nil {
+        t.Fatal("expected connection error")
+    }
+    conn.Close()
+
+    m := c.Metrics()
+    if m.TimeoutErrors != 1 || m.CanceledErrors != 1 || m.SQLErrors != 1 || m.ConnectionErrors != 1 {
+        t.Errorf("unexpected error buckets %+v", m)
+    }
+}
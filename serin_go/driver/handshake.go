@@ -0,0 +1,99 @@
+package driver
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "net"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrUnsupportedServer is matched by errors.Is when the server at the DSN
+// address answered the startup handshake with something other than the
+// PostgreSQL wire protocol SerinDB speaks.
+var ErrUnsupportedServer = errors.New("serin: server does not speak the SerinDB wire protocol")
+
+// UnsupportedServerError carries what could be learned about the server.
+type UnsupportedServerError struct {
+    Addr    string
+    Product string // e.g. "MySQL 8.0.33" or "HTTP server (nginx/1.25)"; empty if unknown
+}
+
+func (e *UnsupportedServerError) Error() string {
+    msg := fmt.Sprintf("serin: server at %s does not speak the SerinDB wire protocol", e.Addr)
+    if e.Product != "" {
+        msg += "; it looks like " + e.Product
+    }
+    return msg + " (check the host and port in the DSN)"
+}
+
+func (e *UnsupportedServerError) Is(target error) bool { return target == ErrUnsupportedServer }
+
+// sniffDial wraps dial so the first bytes each server sends are checked
+// before pgconn parses them.
+func sniffDial(dial pgconn.DialFunc) pgconn.DialFunc {
+    return func(ctx context.Context, network, addr string) (net.Conn, error) {
+        conn, err := dial(ctx, network, addr)
+        if err != nil {
+            return nil, err
+        }
+        return &sniffConn{Conn: conn}, nil
+    }
+}
+
+type sniffConn struct {
+    net.Conn
+    checked bool
+}
+
+func (c *sniffConn) Read(p []byte) (int, error) {
+    n, err := c.Conn.Read(p)
+    if !c.checked && n > 0 {
+        c.checked = true
+        if product, ok := identifyServer(p[:n]); !ok {
+            return 0, &UnsupportedServerError{Addr: c.RemoteAddr().String(), Product: product}
+        }
+    }
+    return n, err
+}
+
+// identifyServer inspects the first bytes received on a new connection. ok is
+// true when they can start a PostgreSQL backend response; otherwise product
+// names a recognised foreign protocol.
+func identifyServer(b []byte) (product string, ok bool) {
+    switch {
+    case bytes.HasPrefix(b, []byte("HTTP/")):
+        product = "an HTTP server"
+        if i := bytes.Index(b, []byte("\r\nServer: ")); i >= 0 {
+            v := b[i+len("\r\nServer: "):]
+            if j := bytes.IndexByte(v, '\r'); j >= 0 {
+                product += " (" + string(v[:j]) + ")"
+            }
+        }
+        return product, false
+    case bytes.HasPrefix(b, []byte("SSH-")):
+        line := b
+        if i := bytes.IndexAny(b, "\r\n"); i >= 0 {
+            line = b[:i]
+        }
+        return "an SSH server (" + string(line) + ")", false
+    case len(b) > 5 && b[3] == 0 && b[4] == 10:
+        // MySQL and MariaDB greet first: 3 byte length, sequence 0, protocol 10.
+        v := b[5:]
+        if i := bytes.IndexByte(v, 0); i >= 0 {
+            return "MySQL " + string(v[:i]), false
+        }
+        return "MySQL", false
+    case bytes.HasPrefix(b, []byte("-ERR")), bytes.HasPrefix(b, []byte("+OK")):
+        return "Redis", false
+    }
+    switch b[0] {
+    case 'S', 'G': // TLS or GSS encryption accepted; the server then waits
+        return "", len(b) == 1
+    case 'N', 'R', 'E', 'v': // encryption refused, authentication, error, protocol negotiation
+        return "", true
+    }
+    return "", false
+}
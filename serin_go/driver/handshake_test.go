@@ -0,0 +1,98 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net"
+    "testing"
+    "time"
+)
+
+// fakeServer accepts connections on a loopback port and hands them to handle.
+func fakeServer(t *testing.T, handle func(net.Conn)) (host, port string) {
+    t.Helper()
+    l, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    serveFake(t, l, handle)
+    host, port, _ = net.SplitHostPort(l.Addr().String())
+    return host, port
+}
+
+// serveFake runs handle for every connection accepted on l until the test ends.
+func serveFake(t *testing.T, l net.Listener, handle func(net.Conn)) {
+    t.Cleanup(func() { l.Close() })
+    go func() {
+        for {
+            c, err := l.Accept()
+            if err != nil {
+                return
+            }
+            go func() {
+                defer c.Close()
+                handle(c)
+            }()
+        }
+    }()
+}
+
+func connectFake(t *testing.T, host, port string) error {
+    t.Helper()
+    c, err := NewConnector("host=" + host + " port=" + port + " user=alice connect_timeout=5")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    conn, err := c.Connect(ctx)
+    if err == nil {
+        conn.Close()
+    }
+    return err
+}
+
+func TestUnsupportedServerEcho(t *testing.T) {
+    host, port := fakeServer(t, func(c net.Conn) { io.Copy(c, c) })
+    start := time.Now()
+    err := connectFake(t, host, port)
+    if !errors.Is(err, ErrUnsupportedServer) {
+        t.Fatalf("got %v, want ErrUnsupportedServer", err)
+    }
+    if time.Since(start) > 2*time.Second {
+        t.Errorf("detection took %s, want fail fast", time.Since(start))
+    }
+}
+
+func TestUnsupportedServerProduct(t *testing.T) {
+    for _, tc := range []struct {
+        greeting string
+        want     string
+    }{
+        {"HTTP/1.1 400 Bad Request\r\nServer: nginx/1.25.3\r\n\r\n", "an HTTP server (nginx/1.25.3)"},
+        {"\x4a\x00\x00\x00\x0a8.0.33\x00\x08\x00\x00\x00", "MySQL 8.0.33"},
+        {"SSH-2.0-OpenSSH_9.3\r\n", "an SSH server (SSH-2.0-OpenSSH_9.3)"},
+    } {
+        host, port := fakeServer(t, func(c net.Conn) {
+            c.Write([]byte(tc.greeting))
+            io.Copy(io.Discard, c)
+        })
+        err := connectFake(t, host, port)
+        var use *UnsupportedServerError
+        if !errors.As(err, &use) {
+            t.Fatalf("got %v, want *UnsupportedServerError", err)
+        }
+        if use.Product != tc.want {
+            t.Errorf("product %q, want %q", use.Product, tc.want)
+        }
+    }
+}
+
+func TestIdentifyServerAcceptsPostgres(t *testing.T) {
+    for _, b := range []string{"S", "N", "R\x00\x00\x00\x08\x00\x00\x00\x00", "E\x00\x00\x00\x10SFATAL\x00"} {
+        if _, ok := identifyServer([]byte(b)); !ok {
+            t.Errorf("rejected %q", b)
+        }
+    }
+}
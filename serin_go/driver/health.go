@@ -0,0 +1,38 @@
+package driver
+
+import (
+    "context"
+    "time"
+)
+
+// Status is the result of DB.HealthCheck.
+type Status struct {
+    Reachable     bool
+    Latency       time.Duration // round trip of the ping
+    ServerVersion string        // server_version reported at startup
+    ReadOnly      bool          // the backend is in recovery (a replica)
+}
+
+// HealthCheck pings the server and reports its version and whether it is in
+// recovery, for use in readiness probes. Reachable is false whenever an error
+// is returned.
+func (db *DB) HealthCheck(ctx context.Context) (Status, error) {
+    var st Status
+    err := withConn(ctx, db.DB, func(c *serinConn) error {
+        start := time.Now()
+        if err := c.conn.Ping(ctx); err != nil {
+            return err
+        }
+        st.Latency = time.Since(start)
+        st.ServerVersion = c.conn.PgConn().ParameterStatus("server_version")
+        if err := c.conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&st.ReadOnly); err != nil {
+            return err
+        }
+        st.Reachable = true
+        return nil
+    })
+    if err != nil {
+        return Status{}, err
+    }
+    return st, nil
+}
@@ -0,0 +1,36 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+    db := Wrap(testDB(t))
+    st, err := db.HealthCheck(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !st.Reachable || st.Latency <= 0 || st.ServerVersion == "" {
+        t.Errorf("unexpected status %+v", st)
+    }
+    var inRecovery bool
+    if err := db.QueryRow("SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+        t.Fatal(err)
+    }
+    if st.ReadOnly != inRecovery {
+        t.Errorf("ReadOnly = %v, server reports in recovery %v", st.ReadOnly, inRecovery)
+    }
+}
+
+func TestHealthCheckUnreachable(t *testing.T) {
+    c, err := NewConnector("host=127.0.0.1 port=1 connect_timeout=1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    st, err := Wrap(sql.OpenDB(c)).HealthCheck(context.Background())
+    if err == nil || st.Reachable {
+        t.Fatalf("got %+v, %v for an unreachable server", st, err)
+    }
+}
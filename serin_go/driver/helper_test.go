@@ -0,0 +1,126 @@
+package driver
+
+import (
+    "database/sql"
+    "os"
+    "testing"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// testDSN returns SERIN_TEST_DSN, skipping the test when the variable is unset.
+func testDSN(t testing.TB) string {
+    t.Helper()
+    dsn := os.Getenv("SERIN_TEST_DSN")
+    if dsn == "" {
+        t.Skip("SERIN_TEST_DSN not set")
+    }
+    return dsn
+}
+
+// testDB opens the server named by SERIN_TEST_DSN.
+func testDB(t testing.TB) *sql.DB {
+    t.Helper()
+    db, err := sql.Open("serin", testDSN(t))
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { db.Close() })
+    return db
+}
+
+// testConnectorDB opens SERIN_TEST_DSN through a Connector built with opts.
+func testConnectorDB(t testing.TB, opts ...Option) (*sql.DB, *Connector) {
+    t.Helper()
+    c, err := NewConnector(testDSN(t), opts...)
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := sql.OpenDB(c)
+    t.Cleanup(func() { db.Close() })
+    return db, c
+}
+
+// mustExec runs each statement on db, failing the test on the first error.
+func mustExec(t testing.TB, db *sql.DB, stmts ...string) {
+    t.Helper()
+    for _, s := range stmts {
+        if _, err := db.Exec(s); err != nil {
+            t.Fatalf("%s: %v", s, err)
+        }
+    }
+}
+
+// fakeRows serves text-format rows from memory so row mapping can be tested
+// without a server. A nil cell is NULL.
+type fakeRows struct {
+    typeMap *pgtype.Map
+    fields  []pgconn.FieldDescription
+    rows    [][][]byte
+    pos     int
+}
+
+type fakeCol struct {
+    name string
+    oid  uint32
+}
+
+func newFakeRows(cols []fakeCol, rows ...[]any) *fakeRows {
+    r := &fakeRows{typeMap: pgtype.NewMap(), pos: -1}
+    for _, c := range cols {
+        r.fields = append(r.fields, pgconn.FieldDescription{Name: c.name, DataTypeOID: c.oid, Format: pgtype.TextFormatCode})
+    }
+    for _, row := range rows {
+        raw := make([][]byte, len(row))
+        for i, v := range row {
+            if v != nil {
+                raw[i] = []byte(v.(string))
+            }
+        }
+        r.rows = append(r.rows, raw)
+    }
+    return r
+}
+
+func (r *fakeRows) Close()                                       {}
+func (r *fakeRows) Err() error                                   { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return r.fields }
+func (r *fakeRows) RawValues() [][]byte                          { return r.rows[r.pos] }
+func (r *fakeRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *fakeRows) Next() bool {
+    r.pos++
+    return r.pos < len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+    if len(dest) == 1 {
+        if rs, ok := dest[0].(pgx.RowScanner); ok {
+            return rs.ScanRow(r)
+        }
+    }
+    return pgx.ScanRow(r.typeMap, r.fields, r.rows[r.pos], dest...)
+}
+
+func (r *fakeRows) Values() ([]any, error) {
+    out := make([]any, len(r.fields))
+    for i, f := range r.fields {
+        if r.rows[r.pos][i] == nil {
+            continue
+        }
+        typ, ok := r.typeMap.TypeForOID(f.DataTypeOID)
+        if !ok {
+            out[i] = string(r.rows[r.pos][i])
+            continue
+        }
+        v, err := typ.Codec.DecodeValue(r.typeMap, f.DataTypeOID, f.Format, r.rows[r.pos][i])
+        if err != nil {
+            return nil, err
+        }
+        out[i] = v
+    }
+    return out, nil
+}
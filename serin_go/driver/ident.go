@@ -0,0 +1,53 @@
+package driver
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidIdentifier is returned for identifiers that cannot be quoted
+// safely: empty names and names containing NUL bytes.
+var ErrInvalidIdentifier = errors.New("serin: invalid identifier")
+
+// Ident quotes parts as a single, possibly schema qualified, SQL identifier
+// for building dynamic SQL. Each part is double quoted with embedded quotes
+// doubled, so case is preserved and the result can never close the quoting:
+//
+//	Ident("public", `My "Table"`) // "public"."My ""Table"""
+func Ident(parts ...string) (string, error) {
+    if len(parts) == 0 {
+        return "", fmt.Errorf("%w: no name", ErrInvalidIdentifier)
+    }
+    for _, p := range parts {
+        if p == "" || strings.IndexByte(p, 0) >= 0 {
+            return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, p)
+        }
+    }
+    return pgx.Identifier(parts).Sanitize(), nil
+}
+
+// tableIdent splits table on dots into a schema qualified identifier, the
+// form the helpers accept table names in.
+func tableIdent(table string) (pgx.Identifier, error) {
+    parts := strings.Split(table, ".")
+    if _, err := Ident(parts...); err != nil {
+        return nil, err
+    }
+    return pgx.Identifier(parts), nil
+}
+
+// columnIdents quotes each of columns.
+func columnIdents(columns []string) ([]string, error) {
+    out := make([]string, len(columns))
+    for i, col := range columns {
+        q, err := Ident(col)
+        if err != nil {
+            return nil, err
+        }
+        out[i] = q
+    }
+    return out, nil
+}
@@ -0,0 +1,51 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+)
+
+func TestIdent(t *testing.T) {
+    for _, tc := range []struct {
+        parts []string
+        want  string
+    }{
+        {[]string{"users"}, `"users"`},
+        {[]string{"MixedCase"}, `"MixedCase"`},
+        {[]string{"public", `My "Table"`}, `"public"."My ""Table"""`},
+        {[]string{`x"; DROP TABLE users; --`}, `"x""; DROP TABLE users; --"`},
+    } {
+        got, err := Ident(tc.parts...)
+        if err != nil || got != tc.want {
+            t.Errorf("Ident(%q) = %s, %v; want %s", tc.parts, got, err, tc.want)
+        }
+    }
+    for _, parts := range [][]string{nil, {""}, {"public", ""}, {"a\x00b"}} {
+        if _, err := Ident(parts...); !errors.Is(err, ErrInvalidIdentifier) {
+            t.Errorf("Ident(%q) accepted: %v", parts, err)
+        }
+    }
+}
+
+func TestNewConnectorRejectsInvalidRole(t *testing.T) {
+    if _, err := NewConnector("host=127.0.0.1", WithRole("app\x00")); !errors.Is(err, ErrInvalidIdentifier) {
+        t.Errorf("got %v", err)
+    }
+}
+
+func TestCopyQuotedNames(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, `DROP TABLE IF EXISTS "Serin ""Quoted"""`, `CREATE TABLE "Serin ""Quoted""" ("Id" int, "say ""hi""" text)`)
+    t.Cleanup(func() { db.Exec(`DROP TABLE "Serin ""Quoted"""`) })
+
+    n, err := Wrap(db).CopyFromCSV(context.Background(), `Serin "Quoted"`, []string{"Id", `say "hi"`}, strings.NewReader("1,a\n2,b\n"), CSVOptions{})
+    if err != nil || n != 2 {
+        t.Fatalf("got %d, %v", n, err)
+    }
+    var sum int
+    if err := db.QueryRow(`SELECT sum("Id") FROM "Serin ""Quoted"""`).Scan(&sum); err != nil || sum != 3 {
+        t.Errorf("got %d, %v", sum, err)
+    }
+}
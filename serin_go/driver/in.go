@@ -0,0 +1,116 @@
+package driver
+
+import (
+    "database/sql/driver"
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// In returns an argument that expands an IN-style membership test for column.
+// Use it in place of a whole predicate:
+//
+//	db.Query("SELECT * FROM users WHERE $1 AND active", driver.In("id", ids))
+//
+// runs "WHERE id = ANY($1) AND active" with ids bound as one array parameter,
+// the idiomatic SerinDB form. column is quoted as an identifier, so it matches
+// case-sensitively and cannot inject SQL; it may be qualified with a dot, as
+// in "u.id", and names that are empty or contain NUL bytes are rejected with
+// ErrInvalidIdentifier. values must be a slice; an empty (or nil) slice
+// matches no rows.
+func In(column string, values any) any {
+    return inArg{column: column, values: values}
+}
+
+type inArg struct {
+    column string
+    values any
+}
+
+// CheckNamedValue lets In arguments, the SimpleProtocol marker and slices
+// reach the driver untouched; pgx encodes slices as arrays of the parameter's
+// element type.
+func (c *serinConn) CheckNamedValue(nv *driver.NamedValue) error { return checkNamedValue(nv) }
+
+func checkNamedValue(nv *driver.NamedValue) error {
+    switch nv.Value.(type) {
+    case inArg, pgx.QueryExecMode:
+        return nil
+    }
+    if isArrayArg(nv.Value) {
+        return nil
+    }
+    return driver.ErrSkip
+}
+
+// expandIn rewrites each placeholder bound to an In argument into an
+// "= ANY" predicate over the argument's slice.
+func expandIn(query string, args []any) (string, []any, error) {
+    var found bool
+    for _, a := range args {
+        if _, ok := a.(inArg); ok {
+            found = true
+            break
+        }
+    }
+    if !found {
+        return query, args, nil
+    }
+    out := make([]any, len(args))
+    copy(out, args)
+    columns := make([]string, len(args))
+    for i, a := range args {
+        in, ok := a.(inArg)
+        if !ok {
+            continue
+        }
+        name, err := tableIdent(in.column)
+        if err != nil {
+            return "", nil, fmt.Errorf("serin: In(%q): %w", in.column, err)
+        }
+        columns[i] = name.Sanitize()
+        v := reflect.ValueOf(in.values)
+        if in.values == nil || v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+            return "", nil, fmt.Errorf("serin: In(%q) needs a slice of values, got %T", in.column, in.values)
+        }
+        if v.IsNil() {
+            v = reflect.MakeSlice(v.Type(), 0, 0)
+        }
+        out[i] = v.Interface()
+    }
+    rewritten := rewritePlaceholders(query, func(n int) (string, bool) {
+        if n < 1 || n > len(args) {
+            return "", false
+        }
+        if _, ok := args[n-1].(inArg); !ok {
+            return "", false
+        }
+        return columns[n-1] + " = ANY($" + strconv.Itoa(n) + ")", true
+    })
+    return rewritten, out, nil
+}
+
+// rewritePlaceholders calls fn for every $n placeholder outside string
+// literals, quoted identifiers and comments, substituting its result when fn
+// reports true.
+func rewritePlaceholders(query string, fn func(n int) (string, bool)) string {
+    var b strings.Builder
+    for i := 0; i < len(query); {
+        kind, n := nextToken(query[i:])
+        tok := query[i : i+n]
+        i += n
+        if kind == tokenPlaceholder {
+            if num, err := strconv.Atoi(tok[1:]); err == nil {
+                if s, ok := fn(num); ok {
+                    b.WriteString(s)
+                    continue
+                }
+            }
+        }
+        b.WriteString(tok)
+    }
+    return b.String()
+}
@@ -0,0 +1,91 @@
+package driver
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+)
+
+func TestExpandIn(t *testing.T) {
+    q, args, err := expandIn("SELECT * FROM t WHERE $1 AND name <> '$1' AND note = $2 -- $1\n AND $3", []any{In("id", []int64{1, 2}), "x", In("tag", []string(nil))})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := `SELECT * FROM t WHERE "id" = ANY($1) AND name <> '$1' AND note = $2 -- $1` + "\n" + ` AND "tag" = ANY($3)`
+    if q != want {
+        t.Errorf("got  %s\nwant %s", q, want)
+    }
+    if !reflect.DeepEqual(args[0], []int64{1, 2}) || args[1] != "x" {
+        t.Errorf("unexpected args %#v", args)
+    }
+    if s, ok := args[2].([]string); !ok || s == nil || len(s) != 0 {
+        t.Errorf("nil slice should bind as an empty array, got %#v", args[2])
+    }
+}
+
+func TestExpandInQuotesColumns(t *testing.T) {
+    for column, want := range map[string]string{
+        `we"ird`:    `SELECT 1 WHERE "we""ird" = ANY($1)`,
+        "1=1 OR x":  `SELECT 1 WHERE "1=1 OR x" = ANY($1)`,
+        "app.users": `SELECT 1 WHERE "app"."users" = ANY($1)`,
+    } {
+        q, _, err := expandIn("SELECT 1 WHERE $1", []any{In(column, []int{1})})
+        if err != nil || q != want {
+            t.Errorf("In(%q): got %s, %v, want %s", column, q, err, want)
+        }
+    }
+    for _, column := range []string{"", "a\x00b", "a."} {
+        if _, _, err := expandIn("SELECT 1 WHERE $1", []any{In(column, []int{1})}); !errors.Is(err, ErrInvalidIdentifier) {
+            t.Errorf("In(%q): got %v, want ErrInvalidIdentifier", column, err)
+        }
+    }
+}
+
+func TestExpandInRejectsNonSlices(t *testing.T) {
+    for _, v := range []any{nil, 42, "abc", []byte("abc")} {
+        if _, _, err := expandIn("SELECT $1", []any{In("id", v)}); err == nil {
+            t.Errorf("In(%#v) accepted", v)
+        }
+    }
+}
+
+func TestRewritePlaceholdersSkipsLiterals(t *testing.T) {
+    for _, q := range []string{
+        `SELECT '$1'`,
+        `SELECT E'\'$1'`,
+        `SELECT "col$1"`,
+        `SELECT $$ $1 $$`,
+        `SELECT $fn$ $1 $fn$`,
+        `SELECT /* $1 /* nested $1 */ $1 */ 1`,
+        `SELECT a$1 FROM t`,
+    } {
+        got := rewritePlaceholders(q, func(int) (string, bool) { return "X", true })
+        if got != q {
+            t.Errorf("rewrote %s into %s", q, got)
+        }
+    }
+}
+
+func TestInQuery(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_in", "CREATE TABLE serin_in (id int8, name text)", "INSERT INTO serin_in VALUES (1, 'a'), (2, 'b'), (3, 'c')")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_in") })
+
+    count := func(query string, args ...any) int {
+        t.Helper()
+        var n int
+        if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+            t.Fatal(err)
+        }
+        return n
+    }
+    if n := count("SELECT count(*) FROM serin_in WHERE $1", In("id", []int64{1, 3, 5})); n != 2 {
+        t.Errorf("int slice matched %d rows, want 2", n)
+    }
+    if n := count("SELECT count(*) FROM serin_in WHERE $1 AND id > $2", In("name", []string{"a", "b"}), 1); n != 1 {
+        t.Errorf("string slice matched %d rows, want 1", n)
+    }
+    if n := count("SELECT count(*) FROM serin_in WHERE $1", In("id", []int64{})); n != 0 {
+        t.Errorf("empty slice matched %d rows, want 0", n)
+    }
+}
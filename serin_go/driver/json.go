@@ -0,0 +1,69 @@
+package driver
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+    "reflect"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// JSON returns a sql.Scanner that unmarshals a json or jsonb column into
+// dest with encoding/json, such as a *[]Item for a json_agg result, a
+// *map[string]any or a pointer to a struct. A NULL column sets a slice, map,
+// pointer or interface dest to nil, while a JSON [] gives an empty non-nil
+// slice. json_agg over no rows returns NULL, so coalesce it with '[]' when
+// the two must not differ.
+//
+//	var items []Item
+//	err := db.QueryRow("SELECT json_agg(i) FROM items i WHERE order_id = $1", id).Scan(driver.JSON(&items))
+//
+// Select and ScanValue decode json columns into slice, map and struct
+// destinations the same way without a wrapper.
+func JSON(dest any) sql.Scanner {
+    return jsonScanner{dest: dest}
+}
+
+type jsonScanner struct {
+    dest any
+}
+
+func (j jsonScanner) Scan(src any) error {
+    rv := reflect.ValueOf(j.dest)
+    if rv.Kind() != reflect.Pointer || rv.IsNil() {
+        return fmt.Errorf("serin: JSON destination must be a non-nil pointer, got %T", j.dest)
+    }
+    var text []byte
+    switch v := src.(type) {
+    case nil:
+        switch rv.Elem().Kind() {
+        case reflect.Slice, reflect.Map, reflect.Pointer, reflect.Interface:
+            rv.Elem().SetZero()
+            return nil
+        }
+        return fmt.Errorf("serin: cannot scan NULL into %T", j.dest)
+    case []byte:
+        text = v
+    case string:
+        text = []byte(v)
+    default:
+        return fmt.Errorf("serin: cannot scan %T into JSON", src)
+    }
+    rv.Elem().SetZero()
+    return json.Unmarshal(text, j.dest)
+}
+
+// jsonText returns the JSON text of a json or jsonb column, so database/sql
+// scans it into strings, byte slices and JSON.
+func jsonText(m *pgtype.Map, oid uint32, format int16, raw []byte) (driver.Value, error) {
+    if raw == nil {
+        return nil, nil
+    }
+    t, ok := m.TypeForOID(oid)
+    if !ok {
+        return nil, fmt.Errorf("serin: unknown type OID %d", oid)
+    }
+    return t.Codec.DecodeDatabaseSQLValue(m, oid, format, raw)
+}
@@ -0,0 +1,68 @@
+package driver
+
+import (
+    "context"
+    "reflect"
+    "testing"
+)
+
+type jsonItem struct {
+    ID   int    `json:"id"`
+    Name string `json:"name"`
+}
+
+func TestJSONScanner(t *testing.T) {
+    items := []jsonItem{{ID: 9}}
+    if err := JSON(&items).Scan([]byte(`[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]`)); err != nil {
+        t.Fatal(err)
+    }
+    if want := []jsonItem{{1, "a"}, {2, "b"}}; !reflect.DeepEqual(items, want) {
+        t.Errorf("got %+v", items)
+    }
+    if err := JSON(&items).Scan("[]"); err != nil || items == nil || len(items) != 0 {
+        t.Errorf("[] gave %#v, %v", items, err)
+    }
+    if err := JSON(&items).Scan(nil); err != nil || items != nil {
+        t.Errorf("NULL gave %#v, %v", items, err)
+    }
+    m := map[string]any{"old": true}
+    if err := JSON(&m).Scan([]byte(`{"k": [1, 2]}`)); err != nil || !reflect.DeepEqual(m, map[string]any{"k": []any{1.0, 2.0}}) {
+        t.Errorf("got %#v, %v", m, err)
+    }
+    var n int
+    if err := JSON(&n).Scan(nil); err == nil {
+        t.Error("NULL scanned into an int")
+    }
+    if err := JSON(items).Scan("[]"); err == nil {
+        t.Error("non-pointer destination accepted")
+    }
+}
+
+func TestJSONAgg(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_json_items", "CREATE TABLE serin_json_items (id int, name text, grp int)",
+        "INSERT INTO serin_json_items VALUES (1, 'a', 1), (2, 'b', 1), (3, 'c', 2)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_json_items") })
+    const q = "SELECT json_agg(json_build_object('id', id, 'name', name) ORDER BY id), jsonb_agg(name ORDER BY id) FROM serin_json_items WHERE grp = $1"
+
+    var items []jsonItem
+    var names string
+    if err := db.QueryRow(q, 1).Scan(JSON(&items), &names); err != nil {
+        t.Fatal(err)
+    }
+    if want := []jsonItem{{1, "a"}, {2, "b"}}; !reflect.DeepEqual(items, want) || names != `["a", "b"]` {
+        t.Errorf("got %+v, %s", items, names)
+    }
+    if err := db.QueryRow(q, 3).Scan(JSON(&items), JSON(&names)); err == nil {
+        t.Error("NULL scanned into a string")
+    }
+    items = []jsonItem{{}}
+    if err := db.QueryRow(q, 3).Scan(JSON(&items), new(any)); err != nil || items != nil {
+        t.Errorf("json_agg over no rows gave %#v, %v", items, err)
+    }
+
+    native, err := ScanValue[[]jsonItem](context.Background(), db, q, 2)
+    if err != nil || !reflect.DeepEqual(native, []jsonItem{{3, "c"}}) {
+        t.Errorf("ScanValue got %+v, %v", native, err)
+    }
+}
@@ -0,0 +1,50 @@
+package driver
+
+import (
+    "errors"
+    "fmt"
+)
+
+// ErrResultTooLarge is returned by Rows.Next once a result set goes past the
+// limits set with WithResultLimit.
+var ErrResultTooLarge = errors.New("serin: result set exceeds the configured limit")
+
+// resultLimit holds the WithResultLimit settings of a Connector.
+type resultLimit struct {
+    rows  int64 // zero disables the row limit
+    bytes int64 // zero disables the byte limit
+}
+
+// WithResultLimit makes a query fail with ErrResultTooLarge when its result
+// set goes past maxRows rows or maxBytes bytes of column data as sent by the
+// server, as a safety net against unbounded scans. Rows within the limits are
+// returned before the error; the server is then asked to cancel the query so
+// the rest is not streamed. Zero disables either limit.
+func WithResultLimit(maxRows, maxBytes int64) Option {
+    return func(c *Connector) { c.resultLimit = resultLimit{rows: maxRows, bytes: maxBytes} }
+}
+
+// checkLimit counts the current row against the connector's result limits.
+func (r *serinRows) checkLimit() error {
+    lim := r.conn.connector.resultLimit
+    if lim.rows <= 0 && lim.bytes <= 0 {
+        return nil
+    }
+    r.seenRows++
+    for _, v := range r.pgRows.RawValues() {
+        r.seenBytes += int64(len(v))
+    }
+    var err error
+    switch {
+    case lim.rows > 0 && r.seenRows > lim.rows:
+        err = fmt.Errorf("%w of %d rows", ErrResultTooLarge, lim.rows)
+    case lim.bytes > 0 && r.seenBytes > lim.bytes:
+        err = fmt.Errorf("%w of %d bytes", ErrResultTooLarge, lim.bytes)
+    default:
+        return nil
+    }
+    // The cancellation error that ends the stream is not counted again.
+    r.failed = true
+    r.conn.conn.PgConn().CancelRequest(r.ctx)
+    return r.conn.failed(r.ctx, err)
+}
@@ -0,0 +1,60 @@
+package driver
+
+import (
+    "database/sql"
+    "errors"
+    "testing"
+)
+
+// countRows reads rows until an error and returns how many it got.
+func countRows(t *testing.T, db *sql.DB, query string) (int, error) {
+    t.Helper()
+    rows, err := db.Query(query)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer rows.Close()
+    n := 0
+    for rows.Next() {
+        n++
+    }
+    return n, rows.Err()
+}
+
+func TestResultRowLimit(t *testing.T) {
+    db, c := testConnectorDB(t, WithResultLimit(10, 0))
+    db.SetMaxOpenConns(1)
+    if n, err := countRows(t, db, "SELECT g FROM generate_series(1, 10) g"); n != 10 || err != nil {
+        t.Errorf("result at the limit: %d rows, %v", n, err)
+    }
+    n, err := countRows(t, db, "SELECT g FROM generate_series(1, 1000000) g")
+    if !errors.Is(err, ErrResultTooLarge) || n != 10 {
+        t.Errorf("got %d rows, %v; want 10 rows and ErrResultTooLarge", n, err)
+    }
+    if m := c.Metrics(); m.CanceledErrors != 0 {
+        t.Errorf("cancellation counted as an error: %+v", m)
+    }
+    var one int
+    if err := db.QueryRow("SELECT 1").Scan(&one); err != nil {
+        t.Errorf("connection unusable after the limit: %v", err)
+    }
+}
+
+func TestResultByteLimit(t *testing.T) {
+    db, _ := testConnectorDB(t, WithResultLimit(0, 5000))
+    n, err := countRows(t, db, "SELECT repeat('x', 1000) FROM generate_series(1, 100)")
+    if !errors.Is(err, ErrResultTooLarge) || n != 5 {
+        t.Errorf("got %d rows, %v; want 5 rows and ErrResultTooLarge", n, err)
+    }
+    if n, err := countRows(t, db, "SELECT repeat('x', 1000) FROM generate_series(1, 5)"); n != 5 || err != nil {
+        t.Errorf("result at the limit: %d rows, %v", n, err)
+    }
+}
+
+func TestResultLimitWithPrefetch(t *testing.T) {
+    db, _ := testConnectorDB(t, WithResultLimit(25, 0), WithResultBufferRows(10))
+    n, err := countRows(t, db, "SELECT g FROM generate_series(1, 1000) g")
+    if !errors.Is(err, ErrResultTooLarge) || n != 25 {
+        t.Errorf("got %d rows, %v; want 25 rows and ErrResultTooLarge", n, err)
+    }
+}
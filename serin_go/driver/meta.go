@@ -0,0 +1,92 @@
+package driver
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "maps"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+type queryMetaKey struct{}
+
+// WithQueryMeta returns a context that attaches meta, such as a user id,
+// request id or purpose, to the queries run with it, on top of metadata
+// already in ctx. The driver adds it to the events it logs, reads it back for
+// hooks with QueryMeta, and with WithQueryMetaSettings makes it visible to the
+// server inside transactions.
+//
+//	ctx = driver.WithQueryMeta(ctx, map[string]string{"request_id": id, "purpose": "export"})
+func WithQueryMeta(ctx context.Context, meta map[string]string) context.Context {
+    merged := maps.Clone(QueryMeta(ctx))
+    if merged == nil {
+        merged = make(map[string]string, len(meta))
+    }
+    maps.Copy(merged, meta)
+    return context.WithValue(ctx, queryMetaKey{}, merged)
+}
+
+// QueryMeta returns the metadata attached to ctx with WithQueryMeta, for log
+// handlers and other hooks that receive the query context. The map must not
+// be modified.
+func QueryMeta(ctx context.Context) map[string]string {
+    meta, _ := ctx.Value(queryMetaKey{}).(map[string]string)
+    return meta
+}
+
+// WithQueryMetaSettings makes statements run inside a transaction set each
+// WithQueryMeta key as the setting prefix.key, local to the transaction like
+// SET LOCAL, so triggers and audit functions can read it with
+// current_setting('app.request_id', true). The settings are sent before the
+// first statement of the transaction and again whenever the metadata changes.
+// Statements outside transactions only pass the metadata to the logger.
+func WithQueryMetaSettings(prefix string) Option {
+    return func(c *Connector) { c.metaPrefix = prefix }
+}
+
+// metaAttr returns the log attribute carrying the metadata of ctx, if any.
+func metaAttr(ctx context.Context) []any {
+    meta := QueryMeta(ctx)
+    if len(meta) == 0 {
+        return nil
+    }
+    return []any{slog.Any("meta", meta)}
+}
+
+// applyMeta sets the metadata of ctx as transaction local settings when
+// WithQueryMetaSettings is enabled and the connection is in a transaction.
+// Keys set earlier in the transaction but missing now are cleared.
+func (c *serinConn) applyMeta(ctx context.Context) error {
+    prefix := c.connector.metaPrefix
+    meta := QueryMeta(ctx)
+    if prefix == "" || c.conn.PgConn().TxStatus() != 'T' || maps.Equal(meta, c.txMeta) {
+        return nil
+    }
+    set := maps.Clone(meta)
+    if set == nil {
+        set = make(map[string]string)
+    }
+    for k := range c.txMeta {
+        if _, ok := set[k]; !ok {
+            set[k] = ""
+        }
+    }
+    keys := make([]string, 0, len(set))
+    for k := range set {
+        keys = append(keys, k)
+    }
+    slices.Sort(keys)
+    calls := make([]string, len(keys))
+    args := make([]any, 0, 2*len(keys))
+    for i, k := range keys {
+        calls[i] = "set_config($" + strconv.Itoa(2*i+1) + ", $" + strconv.Itoa(2*i+2) + ", true)"
+        args = append(args, prefix+"."+k, set[k])
+    }
+    if _, err := c.conn.Exec(ctx, "SELECT "+strings.Join(calls, ", "), args...); err != nil {
+        return c.failed(ctx, fmt.Errorf("serin: applying query metadata: %w", err))
+    }
+    c.txMeta = meta
+    return nil
+}
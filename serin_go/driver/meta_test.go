@@ -0,0 +1,110 @@
+package driver
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "log/slog"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestWithQueryMeta(t *testing.T) {
+    ctx := WithQueryMeta(context.Background(), map[string]string{"user_id": "7", "purpose": "audit"})
+    inner := WithQueryMeta(ctx, map[string]string{"purpose": "export", "request_id": "r1"})
+    want := map[string]string{"user_id": "7", "purpose": "export", "request_id": "r1"}
+    if got := QueryMeta(inner); !reflect.DeepEqual(got, want) {
+        t.Errorf("got %v, want %v", got, want)
+    }
+    if QueryMeta(ctx)["purpose"] != "audit" {
+        t.Error("derived context changed its parent's metadata")
+    }
+    if QueryMeta(context.Background()) != nil {
+        t.Error("metadata without WithQueryMeta")
+    }
+}
+
+func TestQueryMetaReachesLogger(t *testing.T) {
+    var buf bytes.Buffer
+    c, err := NewConnector("host=127.0.0.1", WithSlowQueryLog(time.Millisecond, false), WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+    if err != nil {
+        t.Fatal(err)
+    }
+    sc := &serinConn{connector: c}
+    ctx := WithQueryMeta(context.Background(), map[string]string{"request_id": "r1", "user_id": "7"})
+    sc.observe(ctx, time.Now().Add(-time.Second), "SELECT 1", nil)
+
+    var event struct {
+        Msg  string
+        Meta map[string]string
+    }
+    if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+        t.Fatalf("%v: %s", err, buf.String())
+    }
+    if event.Msg != "serin: slow query" || !reflect.DeepEqual(event.Meta, map[string]string{"request_id": "r1", "user_id": "7"}) {
+        t.Errorf("unexpected event %+v", event)
+    }
+}
+
+func TestQueryMetaSettings(t *testing.T) {
+    db, _ := testConnectorDB(t, WithQueryMetaSettings("app"))
+    ctx := WithQueryMeta(context.Background(), map[string]string{"request_id": "r1"})
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer tx.Rollback()
+    setting := func(ctx context.Context) string {
+        var v string
+        if err := tx.QueryRowContext(ctx, "SELECT current_setting('app.request_id', true)").Scan(&v); err != nil {
+            t.Fatal(err)
+        }
+        return v
+    }
+    if got := setting(ctx); got != "r1" {
+        t.Errorf("app.request_id = %q, want r1", got)
+    }
+    if got := setting(WithQueryMeta(ctx, map[string]string{"request_id": "r2"})); got != "r2" {
+        t.Errorf("app.request_id = %q after changing the metadata, want r2", got)
+    }
+    if got := setting(context.Background()); got != "" {
+        t.Errorf("app.request_id = %q without metadata, want it cleared", got)
+    }
+    if err := tx.Commit(); err != nil {
+        t.Fatal(err)
+    }
+    var v string
+    if err := db.QueryRowContext(ctx, "SELECT coalesce(current_setting('app.request_id', true), '')").Scan(&v); err != nil || v != "" {
+        t.Errorf("setting outlived the transaction: %q, %v", v, err)
+    }
+}
+
+func TestBatchInsertQueryMetaSettings(t *testing.T) {
+    db, _ := testConnectorDB(t, WithQueryMetaSettings("app"))
+    db.SetMaxOpenConns(1)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_batch_meta",
+        "CREATE TABLE serin_batch_meta (id int8, request_id text DEFAULT current_setting('app.request_id', true))")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_batch_meta") })
+    ctx := WithQueryMeta(context.Background(), map[string]string{"request_id": "r1"})
+
+    // An earlier transaction on the same connection with the same metadata
+    // must not make BatchInsert skip sending it.
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := tx.ExecContext(ctx, "SELECT 1"); err != nil {
+        t.Fatal(err)
+    }
+    if err := tx.Commit(); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := Wrap(db).BatchInsert(ctx, "serin_batch_meta", []string{"id"}, [][]any{{int64(1)}, {int64(2)}}, 0); err != nil {
+        t.Fatal(err)
+    }
+    var n int
+    if err := db.QueryRow("SELECT count(*) FROM serin_batch_meta WHERE request_id = 'r1'").Scan(&n); err != nil || n != 2 {
+        t.Errorf("%d rows carry the metadata, want 2 (%v)", n, err)
+    }
+}
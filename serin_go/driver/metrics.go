@@ -0,0 +1,47 @@
+package driver
+
+import "sync/atomic"
+
+// Metrics collects driver counters shared by every connection of a Connector.
+type Metrics struct {
+    stmtCacheHits      atomic.Int64
+    stmtCacheMisses    atomic.Int64
+    stmtCacheEvictions atomic.Int64
+    stmtReprepares     atomic.Int64
+    errorsByKind       [ErrorDeadlock + 1]atomic.Int64
+}
+
+// MetricsSnapshot is a point-in-time copy of Metrics suitable for exporting.
+type MetricsSnapshot struct {
+    StatementCacheHits      int64
+    StatementCacheMisses    int64
+    StatementCacheEvictions int64
+    // Cached statements prepared again after a schema change invalidated
+    // their result type.
+    StatementReprepares int64
+
+    // Query failures by ErrorKind.
+    TimeoutErrors    int64
+    CanceledErrors   int64
+    ConnectionErrors int64
+    SQLErrors        int64
+    DeadlockErrors   int64
+    OtherErrors      int64
+}
+
+func (m *Metrics) snapshot() MetricsSnapshot {
+    return MetricsSnapshot{
+        StatementCacheHits:      m.stmtCacheHits.Load(),
+        StatementCacheMisses:    m.stmtCacheMisses.Load(),
+        StatementCacheEvictions: m.stmtCacheEvictions.Load(),
+        StatementReprepares:     m.stmtReprepares.Load(),
+        TimeoutErrors:           m.errorsByKind[ErrorTimeout].Load(),
+        CanceledErrors:          m.errorsByKind[ErrorCanceled].Load(),
+        ConnectionErrors:        m.errorsByKind[ErrorConnection].Load(),
+        SQLErrors:               m.errorsByKind[ErrorSQL].Load(),
+        DeadlockErrors:          m.errorsByKind[ErrorDeadlock].Load(),
+        OtherErrors:             m.errorsByKind[ErrorOther].Load(),
+    }
+}
+
+func (m *Metrics) recordError(kind ErrorKind) { m.errorsByKind[kind].Add(1) }
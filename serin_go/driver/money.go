@@ -0,0 +1,166 @@
+package driver
+
+import (
+    "context"
+    "database/sql/driver"
+    "encoding/binary"
+    "fmt"
+    "strconv"
+    "strings"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// moneyOID is the type OID of money, which pgx does not register.
+const moneyOID = 790
+
+// MoneyMode selects how money values are returned.
+type MoneyMode int
+
+const (
+    // MoneyText returns money as the server formats it for lc_monetary,
+    // such as "$1,234.56". This is the default.
+    MoneyText MoneyMode = iota
+    // MoneyCents returns money as an int64 count of the currency's minor
+    // unit (cents for most currencies).
+    MoneyCents
+    // MoneyDecimal returns money as a plain decimal string with the
+    // currency's number of fraction digits, such as "1234.56".
+    MoneyDecimal
+)
+
+// WithMoneyMode sets how money values are returned, overriding the
+// money_mode DSN parameter (text, cents or decimal).
+func WithMoneyMode(mode MoneyMode) Option {
+    return func(c *Connector) { c.moneyMode = mode }
+}
+
+func parseMoneyMode(s string) (MoneyMode, error) {
+    switch strings.ToLower(s) {
+    case "text":
+        return MoneyText, nil
+    case "cents":
+        return MoneyCents, nil
+    case "decimal":
+        return MoneyDecimal, nil
+    }
+    return 0, fmt.Errorf("serin: invalid money_mode %q, want text, cents or decimal", s)
+}
+
+// registerMoney makes sc read money in its exact binary form, an int64 of
+// minor units, and looks up the number of fraction digits of the session's
+// currency for MoneyDecimal.
+func (c *Connector) registerMoney(ctx context.Context, sc *serinConn) error {
+    if c.moneyMode == MoneyText {
+        return nil
+    }
+    sc.conn.TypeMap().RegisterType(&pgtype.Type{Name: "money", OID: moneyOID, Codec: moneyCodec{}})
+    if c.moneyMode == MoneyDecimal {
+        if err := sc.conn.QueryRow(ctx, "SELECT scale(1::money::numeric)").Scan(&sc.moneyDigits); err != nil {
+            return fmt.Errorf("serin: reading money precision: %w", err)
+        }
+    }
+    return nil
+}
+
+// moneyValue converts a money column value decoded by moneyCodec.
+func (c *serinConn) moneyValue(v any) driver.Value {
+    cents, ok := v.(int64)
+    if !ok || c.connector.moneyMode != MoneyDecimal {
+        return v
+    }
+    return formatMoney(cents, c.moneyDigits)
+}
+
+// formatMoney renders minor units as a decimal with digits fraction digits.
+func formatMoney(units int64, digits int) string {
+    s := strconv.FormatInt(units, 10)
+    sign := ""
+    if units < 0 {
+        sign, s = "-", s[1:]
+    }
+    if digits <= 0 {
+        return sign + s
+    }
+    if len(s) <= digits {
+        s = strings.Repeat("0", digits-len(s)+1) + s
+    }
+    return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// parseMoneyText extracts minor units from the server's text form of money.
+// The output always carries the currency's full fraction digits, so dropping
+// currency symbols, group and decimal separators leaves the minor units.
+func parseMoneyText(src []byte) (int64, error) {
+    var b strings.Builder
+    negative := false
+    for _, ch := range src {
+        switch {
+        case ch >= '0' && ch <= '9':
+            b.WriteByte(ch)
+        case ch == '-' || ch == '(':
+            negative = true
+        }
+    }
+    n, err := strconv.ParseInt(b.String(), 10, 64)
+    if err != nil {
+        return 0, fmt.Errorf("serin: invalid money value %q", src)
+    }
+    if negative {
+        n = -n
+    }
+    return n, nil
+}
+
+// moneyCodec is the int8 codec, which matches the binary form of money,
+// extended to read the locale formatted text form sent by the simple
+// protocol.
+type moneyCodec struct {
+    pgtype.Int8Codec
+}
+
+// PlanEncode only encodes binary: integers are minor units there, while in
+// text the server would read them as whole currency units.
+func (c moneyCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+    if format != pgtype.BinaryFormatCode {
+        return nil
+    }
+    return c.Int8Codec.PlanEncode(m, oid, format, value)
+}
+
+func (c moneyCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+    next := c.Int8Codec.PlanScan(m, oid, pgtype.BinaryFormatCode, target)
+    if format == pgtype.BinaryFormatCode || next == nil {
+        return next
+    }
+    return moneyTextScanPlan{next: next}
+}
+
+func (c moneyCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+    return c.DecodeValue(m, oid, format, src)
+}
+
+func (c moneyCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+    if src == nil {
+        return nil, nil
+    }
+    if format == pgtype.TextFormatCode {
+        return parseMoneyText(src)
+    }
+    return c.Int8Codec.DecodeValue(m, oid, format, src)
+}
+
+type moneyTextScanPlan struct {
+    next pgtype.ScanPlan
+}
+
+func (p moneyTextScanPlan) Scan(src []byte, dst any) error {
+    if src == nil {
+        return p.next.Scan(nil, dst)
+    }
+    n, err := parseMoneyText(src)
+    if err != nil {
+        return err
+    }
+    return p.next.Scan(binary.BigEndian.AppendUint64(nil, uint64(n)), dst)
+}
@@ -0,0 +1,96 @@
+package driver
+
+import (
+    "database/sql"
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestFormatMoney(t *testing.T) {
+    for _, tc := range []struct {
+        units  int64
+        digits int
+        want   string
+    }{
+        {123456, 2, "1234.56"},
+        {5, 2, "0.05"},
+        {-5, 2, "-0.05"},
+        {-110, 2, "-1.10"},
+        {0, 2, "0.00"},
+        {1500, 0, "1500"},
+        {1234, 3, "1.234"},
+    } {
+        if got := formatMoney(tc.units, tc.digits); got != tc.want {
+            t.Errorf("formatMoney(%d, %d) = %s, want %s", tc.units, tc.digits, got, tc.want)
+        }
+    }
+}
+
+func TestParseMoneyText(t *testing.T) {
+    for in, want := range map[string]int64{"$1,234.56": 123456, "-$1.10": -110, "($0.05)": -5, "1.234,56 €": 123456, "¥1,500": 1500} {
+        if got, err := parseMoneyText([]byte(in)); err != nil || got != want {
+            t.Errorf("parseMoneyText(%q) = %d, %v; want %d", in, got, err, want)
+        }
+    }
+}
+
+func TestMoneyCodecScan(t *testing.T) {
+    m := pgtype.NewMap()
+    m.RegisterType(&pgtype.Type{Name: "money", OID: moneyOID, Codec: moneyCodec{}})
+    var n int64
+    if err := m.Scan(moneyOID, pgtype.TextFormatCode, []byte("-$12.34"), &n); err != nil || n != -1234 {
+        t.Errorf("text: got %d, %v", n, err)
+    }
+    if err := m.Scan(moneyOID, pgtype.BinaryFormatCode, []byte{0, 0, 0, 0, 0, 0, 0x04, 0xd2}, &n); err != nil || n != 1234 {
+        t.Errorf("binary: got %d, %v", n, err)
+    }
+}
+
+func TestMoneyModeParam(t *testing.T) {
+    for dsn, want := range map[string]MoneyMode{"money_mode=cents": MoneyCents, "money_mode=DECIMAL": MoneyDecimal, "": MoneyText} {
+        c, err := NewConnector("host=127.0.0.1 " + dsn)
+        if err != nil || c.moneyMode != want {
+            t.Errorf("%q: got %v, %v", dsn, c.moneyMode, err)
+        }
+    }
+    if _, err := NewConnector("host=127.0.0.1 money_mode=float"); err == nil {
+        t.Error("invalid money_mode accepted")
+    }
+}
+
+func moneyTable(t *testing.T, db *sql.DB) {
+    t.Helper()
+    // 2.205 and -0.005 are rounded by the server on input, half away from zero.
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_money", "CREATE TABLE serin_money (amount money)", "INSERT INTO serin_money VALUES ('1.10'), ('2.205'), ('-0.005')")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_money") })
+}
+
+func TestMoneyCents(t *testing.T) {
+    db, _ := testConnectorDB(t, WithMoneyMode(MoneyCents))
+    moneyTable(t, db)
+    var sum, row int64
+    if err := db.QueryRow("SELECT sum(amount) FROM serin_money").Scan(&sum); err != nil || sum != 330 {
+        t.Errorf("sum: got %d, %v; want 330", sum, err)
+    }
+    if err := db.QueryRow("SELECT amount FROM serin_money WHERE amount > $1 ORDER BY amount", int64(110)).Scan(&row); err != nil || row != 221 {
+        t.Errorf("row: got %d, %v; want 221", row, err)
+    }
+    // The simple protocol returns formatted text, which is read back as cents too.
+    if err := db.QueryRow("SELECT sum(amount) FROM serin_money", SimpleProtocol).Scan(&sum); err != nil || sum != 330 {
+        t.Errorf("simple protocol sum: got %d, %v; want 330", sum, err)
+    }
+}
+
+func TestMoneyDecimal(t *testing.T) {
+    db, _ := testConnectorDB(t, WithMoneyMode(MoneyDecimal))
+    moneyTable(t, db)
+    var sum string
+    if err := db.QueryRow("SELECT sum(amount) FROM serin_money").Scan(&sum); err != nil || sum != "3.30" {
+        t.Errorf("sum: got %s, %v; want 3.30", sum, err)
+    }
+    var neg string
+    if err := db.QueryRow("SELECT min(amount) FROM serin_money").Scan(&neg); err != nil || neg != "-0.01" {
+        t.Errorf("min: got %s, %v; want -0.01", neg, err)
+    }
+}
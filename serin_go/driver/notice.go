@@ -0,0 +1,74 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "sync"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// Notice is a message below error severity that the server sent while running
+// a statement, such as the output of RAISE NOTICE in a PL/pgSQL function.
+type Notice = pgconn.Notice
+
+type noticeKey struct{}
+
+// WithNoticeHandler returns a context whose statements pass every notice the
+// server sends while running them to fn, including notices sent while their
+// rows are read. Notices of statements run with other contexts on the same
+// connection are not seen. fn runs on the goroutine that drives the statement
+// and must not use its connection.
+func WithNoticeHandler(ctx context.Context, fn func(n *Notice)) context.Context {
+    return context.WithValue(ctx, noticeKey{}, fn)
+}
+
+func noticeHandler(ctx context.Context) func(n *Notice) {
+    fn, _ := ctx.Value(noticeKey{}).(func(n *Notice))
+    return fn
+}
+
+// notice passes a notice to the handler of the running statement.
+func (c *serinConn) notice(n *Notice) {
+    if c.onNotice != nil {
+        c.onNotice(n)
+    }
+}
+
+// Notices buffers the notices of one query. It is safe for concurrent use.
+type Notices struct {
+    mu   sync.Mutex
+    list []Notice
+}
+
+// List returns the notices received so far, in the order the server sent them.
+func (n *Notices) List() []Notice {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    return append([]Notice(nil), n.list...)
+}
+
+func (n *Notices) add(notice *Notice) {
+    n.mu.Lock()
+    n.list = append(n.list, *notice)
+    n.mu.Unlock()
+}
+
+// QueryWithNotices runs query like QueryContext and buffers the notices it
+// raises, such as progress reported with RAISE NOTICE by a function it calls.
+// The server may send notices at any point of the result, so the buffer is
+// complete only once the rows have been read to the end or closed.
+//
+//	rows, notices, err := db.QueryWithNotices(ctx, "SELECT rebuild_stats($1)", day)
+//	if err != nil { ... }
+//	for rows.Next() { ... }
+//	rows.Close()
+//	for _, n := range notices.List() { log.Println(n.Severity, n.Message) }
+func (db *DB) QueryWithNotices(ctx context.Context, query string, args ...any) (*sql.Rows, *Notices, error) {
+    notices := new(Notices)
+    rows, err := db.QueryContext(WithNoticeHandler(ctx, notices.add), query, args...)
+    if err != nil {
+        return nil, notices, err
+    }
+    return rows, notices, nil
+}
@@ -0,0 +1,120 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "net"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgproto3"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// noticeServer fakes a server whose progress() function raises a notice
+// before and after its row.
+func noticeServer(t *testing.T) (host, port string) {
+    return fakeServer(t, serveNotices)
+}
+
+func serveNotices(c net.Conn) {
+    be := pgproto3.NewBackend(c, c)
+    if _, err := be.ReceiveStartupMessage(); err != nil {
+        return
+    }
+    be.Send(&pgproto3.AuthenticationOk{})
+    be.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+    be.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+    be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+    be.Flush()
+    for {
+        msg, err := be.Receive()
+        if err != nil {
+            return
+        }
+        q, ok := msg.(*pgproto3.Query)
+        if !ok {
+            return
+        }
+        if strings.Contains(q.String, "progress()") {
+            be.Send(&pgproto3.NoticeResponse{Severity: "NOTICE", Code: "00000", Message: "step 1"})
+            be.Send(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{{Name: []byte("progress"), DataTypeOID: pgtype.Int4OID, DataTypeSize: 4, TypeModifier: -1}}})
+            be.Send(&pgproto3.DataRow{Values: [][]byte{[]byte("7")}})
+            be.Send(&pgproto3.NoticeResponse{Severity: "WARNING", Code: "01000", Message: "step 2"})
+            be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")})
+        } else {
+            be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 0")})
+        }
+        be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+        be.Flush()
+    }
+}
+
+func TestQueryWithNoticesBuffersPerQuery(t *testing.T) {
+    host, port := noticeServer(t)
+    c, err := NewConnector("host=" + host + " port=" + port + " user=alice sslmode=disable")
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := Wrap(sql.OpenDB(c))
+    defer db.Close()
+    db.SetMaxOpenConns(1)
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    rows, notices, err := db.QueryWithNotices(ctx, "SELECT progress()", SimpleProtocol)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for rows.Next() {
+    }
+    if err := rows.Close(); err != nil {
+        t.Fatal(err)
+    }
+    got := notices.List()
+    if len(got) != 2 || got[0].Message != "step 1" || got[1].Message != "step 2" || got[1].Severity != "WARNING" {
+        t.Fatalf("got %+v", got)
+    }
+
+    // Later statements on the same connection do not reach the buffer.
+    var n int
+    if err := db.QueryRowContext(ctx, "SELECT progress()", SimpleProtocol).Scan(&n); err != nil || n != 7 {
+        t.Fatalf("got %d, %v", n, err)
+    }
+    if got := notices.List(); len(got) != 2 {
+        t.Errorf("notices of another query buffered: %+v", got)
+    }
+}
+
+func TestQueryWithNoticesRaiseNotice(t *testing.T) {
+    db := Wrap(testDB(t))
+    mustExec(t, db.DB, `CREATE OR REPLACE FUNCTION serin_progress(n int) RETURNS int AS $$
+BEGIN
+    FOR i IN 1..n LOOP
+        RAISE NOTICE 'step % of %', i, n;
+    END LOOP;
+    RETURN n;
+END;
+$$ LANGUAGE plpgsql`)
+    t.Cleanup(func() { db.Exec("DROP FUNCTION serin_progress(int)") })
+
+    rows, notices, err := db.QueryWithNotices(context.Background(), "SELECT serin_progress($1)", 3)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for rows.Next() {
+    }
+    if err := rows.Close(); err != nil {
+        t.Fatal(err)
+    }
+    got := notices.List()
+    if len(got) != 3 {
+        t.Fatalf("got %d notices: %+v", len(got), got)
+    }
+    for i, n := range got {
+        if want := "step " + string(rune('1'+i)) + " of 3"; n.Message != want || n.Severity != "NOTICE" {
+            t.Errorf("notice %d: %s %q, want NOTICE %q", i, n.Severity, n.Message, want)
+        }
+    }
+}
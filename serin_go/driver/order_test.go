@@ -0,0 +1,51 @@
+package driver
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestOrderBy(t *testing.T) {
+    got, err := OrderBy("name", "u.created_at", "Id").Sort("u.created_at", "DESC").Sort("name", "").Asc("Id").SQL()
+    want := `ORDER BY "u"."created_at" DESC, "name" ASC, "Id" ASC`
+    if err != nil || got != want {
+        t.Errorf("got %s, %v; want %s", got, err, want)
+    }
+    if got, err := OrderBy("name").SQL(); got != "" || err != nil {
+        t.Errorf("empty clause: %q, %v", got, err)
+    }
+
+    for _, col := range []string{"email", "id", "name; DROP TABLE users", `name" DESC, "x`, ""} {
+        if _, err := OrderBy("name", "Id").Asc(col).SQL(); !errors.Is(err, ErrColumnNotAllowed) {
+            t.Errorf("column %q: got %v", col, err)
+        }
+    }
+    for _, dir := range []string{"up", "DESC NULLS FIRST", "desc; DROP TABLE users", "1"} {
+        if _, err := OrderBy("name").Sort("name", dir).SQL(); !errors.Is(err, ErrInvalidSortDirection) {
+            t.Errorf("direction %q: got %v", dir, err)
+        }
+    }
+    // The first error wins over later valid terms.
+    if _, err := OrderBy("name").Desc("secret").Asc("name").SQL(); !errors.Is(err, ErrColumnNotAllowed) {
+        t.Errorf("got %v", err)
+    }
+}
+
+func TestColumns(t *testing.T) {
+    allowed := []string{"id", "name", "u.email"}
+    got, err := Columns(allowed, []string{"u.email", "id"})
+    if err != nil || got != `"u"."email", "id"` {
+        t.Errorf("got %s, %v", got, err)
+    }
+    if got, err := Columns(allowed, nil); err != nil || got != `"id", "name", "u"."email"` {
+        t.Errorf("default projection: %s, %v", got, err)
+    }
+    for _, req := range [][]string{{"password"}, {"id", "*"}, {"Name"}, {"id) FROM users; --"}} {
+        if _, err := Columns(allowed, req); !errors.Is(err, ErrColumnNotAllowed) {
+            t.Errorf("Columns(%q) accepted: %v", req, err)
+        }
+    }
+    if _, err := Columns(nil, []string{"id"}); !errors.Is(err, ErrColumnNotAllowed) {
+        t.Errorf("empty allowlist accepted: %v", err)
+    }
+}
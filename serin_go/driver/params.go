@@ -0,0 +1,107 @@
+package driver
+
+import (
+    "context"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// binaryParamOIDs are the parameter types sent in binary when the statement
+// cache is enabled (see encodeParams). Their binary forms are compact and
+// stable across server versions, while text is expensive to produce or parse
+// for them (bytea escaping, numeric and timestamp formatting). Every other
+// type, including enums, domains and types of extensions, is sent as text,
+// which the server accepts for any input.
+var binaryParamOIDs = map[uint32]bool{
+    pgtype.ByteaOID:       true,
+    pgtype.NumericOID:     true,
+    pgtype.TimestampOID:   true,
+    pgtype.TimestamptzOID: true,
+    pgtype.DateOID:        true,
+    pgtype.IntervalOID:    true,
+    pgtype.BoolOID:        true,
+    pgtype.Int2OID:        true,
+    pgtype.Int4OID:        true,
+    pgtype.Int8OID:        true,
+    pgtype.Float4OID:      true,
+    pgtype.Float8OID:      true,
+    pgtype.UUIDOID:        true,
+    moneyOID:              true,
+}
+
+// paramFormat returns the format to send a parameter of type oid in, judging
+// arrays by their element type.
+func paramFormat(m *pgtype.Map, oid uint32) int16 {
+    if t, ok := m.TypeForOID(oid); ok {
+        if ac, ok := t.Codec.(*pgtype.ArrayCodec); ok {
+            oid = ac.ElementType.OID
+        }
+    }
+    if binaryParamOIDs[oid] {
+        return pgtype.BinaryFormatCode
+    }
+    return pgtype.TextFormatCode
+}
+
+// encodeParams chooses a format for each argument from the type of the
+// parameter it binds to. pgx sends a string argument as text and everything
+// else as binary; arguments bound to text-format parameters are encoded here
+// as text with the parameter's codec so that pgx sends them as strings.
+// Slices whose element type pgx cannot bind in binary (such as []string for
+// uuid[]) are encoded as text array literals the same way. name is the
+// statement cached for query, or query itself when it is not cached, in which
+// case the query is described first. prepare only runs it for statements
+// outside the cache when arrays are bound or enums are registered, as
+// describing every statement would cost a round trip; otherwise pgx picks
+// each format from the argument's Go type.
+func (c *serinConn) encodeParams(ctx context.Context, name, query string, args []any) ([]any, error) {
+    if name == query {
+        name = ""
+    }
+    sd, err := c.conn.Prepare(ctx, name, query)
+    if err != nil {
+        return nil, err
+    }
+    m := c.conn.TypeMap()
+    for i, a := range args {
+        if i >= len(sd.ParamOIDs) {
+            break
+        }
+        oid := sd.ParamOIDs[i]
+        if c.enums {
+            if err := checkEnum(m, oid, a); err != nil {
+                return nil, err
+            }
+        }
+        switch a.(type) {
+        case nil, string:
+            continue
+        }
+        if paramFormat(m, oid) == pgtype.BinaryFormatCode && m.PlanEncode(oid, pgtype.BinaryFormatCode, a) != nil {
+            continue
+        }
+        if m.PlanEncode(oid, pgtype.TextFormatCode, a) == nil {
+            // Leave it for pgx to bind or report with its usual error.
+            continue
+        }
+        buf, err := m.Encode(oid, pgtype.TextFormatCode, a, nil)
+        if err != nil {
+            return nil, err
+        }
+        if buf == nil {
+            args[i] = nil
+        } else {
+            args[i] = string(buf)
+        }
+    }
+    return args, nil
+}
+
+func hasArrayArg(args []any) bool {
+    for _, a := range args {
+        if isArrayArg(a) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,69 @@
+package driver
+
+import (
+    "bytes"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParamFormat(t *testing.T) {
+    m := pgtype.NewMap()
+    for oid, want := range map[uint32]int16{
+        pgtype.ByteaOID:       pgtype.BinaryFormatCode,
+        pgtype.NumericOID:     pgtype.BinaryFormatCode,
+        pgtype.TimestamptzOID: pgtype.BinaryFormatCode,
+        pgtype.Int8ArrayOID:   pgtype.BinaryFormatCode,
+        pgtype.TextOID:        pgtype.TextFormatCode,
+        pgtype.JSONBOID:       pgtype.TextFormatCode,
+        pgtype.InetArrayOID:   pgtype.TextFormatCode,
+        999999:                pgtype.TextFormatCode,
+    } {
+        if got := paramFormat(m, oid); got != want {
+            t.Errorf("OID %d: got format %d, want %d", oid, got, want)
+        }
+    }
+}
+
+func TestMixedParams(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_params", "CREATE TABLE serin_params (b bytea, n numeric, at timestamptz, t text, j jsonb, addr inet)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_params") })
+
+    blob := bytes.Repeat([]byte{0, 1, 2, 0xff}, 1024)
+    at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+    if _, err := db.Exec("INSERT INTO serin_params VALUES ($1, $2, $3, $4, $5, $6)", blob, 12.5, at, "forty-two", []byte(`{"k": "v"}`), "10.1.2.3"); err != nil {
+        t.Fatal(err)
+    }
+    var (
+        gotBlob []byte
+        gotN    string
+        gotAt   time.Time
+        gotT    string
+        gotJ    string
+        gotAddr string
+    )
+    if err := db.QueryRow("SELECT b, n::text, at, t, j::text, host(addr) FROM serin_params").Scan(&gotBlob, &gotN, &gotAt, &gotT, &gotJ, &gotAddr); err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(gotBlob, blob) || gotN != "12.5" || !gotAt.Equal(at) || gotT != "forty-two" || gotJ != `{"k": "v"}` || gotAddr != "10.1.2.3" {
+        t.Errorf("got %d bytes, %s, %v, %s, %s, %s", len(gotBlob), gotN, gotAt, gotT, gotJ, gotAddr)
+    }
+}
+
+func BenchmarkMixedParams(b *testing.B) {
+    db := testDB(b)
+    mustExec(b, db, "DROP TABLE IF EXISTS serin_params_bench", "CREATE UNLOGGED TABLE serin_params_bench (b bytea, n numeric, at timestamptz, t text, j jsonb)")
+    b.Cleanup(func() { db.Exec("DROP TABLE serin_params_bench") })
+    blob := bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 16<<10)
+    at := time.Now()
+    doc := []byte(`{"tags": ["a", "b"], "n": 1}`)
+    b.SetBytes(int64(len(blob)))
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := db.Exec("INSERT INTO serin_params_bench VALUES ($1, $2, $3, $4, $5)", blob, float64(i)/7, at, "row", doc); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
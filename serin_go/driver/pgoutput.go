@@ -0,0 +1,204 @@
+package driver
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// errShortMessage reports a pgoutput message that ended early.
+var errShortMessage = errors.New("serin: truncated logical replication message")
+
+// relation is the table layout announced by a pgoutput Relation message.
+type relation struct {
+    schema, table string
+    columns       []relationColumn
+}
+
+type relationColumn struct {
+    name string
+    oid  uint32
+}
+
+// pgoutputDecoder turns pgoutput protocol version 1 messages into change
+// events, remembering the relations announced so far.
+type pgoutputDecoder struct {
+    m         *pgtype.Map
+    relations map[uint32]relation
+}
+
+func newPgoutputDecoder(m *pgtype.Map) *pgoutputDecoder {
+    return &pgoutputDecoder{m: m, relations: make(map[uint32]relation)}
+}
+
+// decode handles one pgoutput message. It returns nil for messages that do
+// not carry a row change, such as Begin, Relation and Type. For Commit it
+// returns the end LSN of the transaction with a nil event so the stream can
+// confirm it.
+func (d *pgoutputDecoder) decode(data []byte) (*ChangeEvent, LSN, error) {
+    if len(data) == 0 {
+        return nil, 0, errShortMessage
+    }
+    r := &msgReader{buf: data[1:]}
+    switch data[0] {
+    case 'R':
+        id := r.uint32()
+        rel := relation{schema: r.cstring(), table: r.cstring()}
+        r.byte() // replica identity setting
+        n := int(r.uint16())
+        for i := 0; i < n && r.err == nil; i++ {
+            r.byte() // flags
+            col := relationColumn{name: r.cstring(), oid: r.uint32()}
+            r.uint32() // type modifier
+            rel.columns = append(rel.columns, col)
+        }
+        if r.err != nil {
+            return nil, 0, r.err
+        }
+        d.relations[id] = rel
+        return nil, 0, nil
+    case 'C':
+        r.byte()   // flags
+        r.uint64() // commit LSN
+        end := LSN(r.uint64())
+        return nil, end, r.err
+    case 'I', 'U', 'D':
+        return d.decodeChange(data[0], r)
+    }
+    return nil, 0, nil
+}
+
+func (d *pgoutputDecoder) decodeChange(kind byte, r *msgReader) (*ChangeEvent, LSN, error) {
+    id := r.uint32()
+    rel, ok := d.relations[id]
+    if r.err == nil && !ok {
+        return nil, 0, fmt.Errorf("serin: change for unknown relation %d", id)
+    }
+    ev := &ChangeEvent{Schema: rel.schema, Table: rel.table}
+    switch kind {
+    case 'I':
+        ev.Kind = ChangeInsert
+    case 'U':
+        ev.Kind = ChangeUpdate
+    case 'D':
+        ev.Kind = ChangeDelete
+    }
+    for r.err == nil && len(r.buf) > 0 {
+        var err error
+        switch tag := r.byte(); tag {
+        case 'K', 'O':
+            ev.Old, err = d.tuple(rel, r)
+        case 'N':
+            ev.New, err = d.tuple(rel, r)
+        default:
+            return nil, 0, fmt.Errorf("serin: unexpected tuple tag %q", tag)
+        }
+        if err != nil {
+            return nil, 0, err
+        }
+    }
+    return ev, 0, r.err
+}
+
+// tuple decodes TupleData into column values. Unchanged TOASTed values are
+// not sent by the server and are left out of the map.
+func (d *pgoutputDecoder) tuple(rel relation, r *msgReader) (map[string]any, error) {
+    n := int(r.uint16())
+    if r.err == nil && n > len(rel.columns) {
+        return nil, fmt.Errorf("serin: tuple has %d columns, relation %s.%s has %d", n, rel.schema, rel.table, len(rel.columns))
+    }
+    row := make(map[string]any, n)
+    for i := 0; i < n && r.err == nil; i++ {
+        col := rel.columns[i]
+        switch kind := r.byte(); kind {
+        case 'n':
+            row[col.name] = nil
+        case 'u':
+        case 't', 'b':
+            v := r.bytes(int(r.uint32()))
+            if r.err != nil {
+                break
+            }
+            format := int16(pgtype.TextFormatCode)
+            if kind == 'b' {
+                format = pgtype.BinaryFormatCode
+            }
+            val, err := d.value(col.oid, format, v)
+            if err != nil {
+                return nil, fmt.Errorf("serin: column %s: %w", col.name, err)
+            }
+            row[col.name] = val
+        default:
+            return nil, fmt.Errorf("serin: unexpected column kind %q", kind)
+        }
+    }
+    return row, r.err
+}
+
+func (d *pgoutputDecoder) value(oid uint32, format int16, v []byte) (any, error) {
+    if t, ok := d.m.TypeForOID(oid); ok {
+        return t.Codec.DecodeValue(d.m, oid, format, v)
+    }
+    if format == pgtype.TextFormatCode {
+        return string(v), nil
+    }
+    return append([]byte(nil), v...), nil
+}
+
+// msgReader reads big-endian protocol fields, remembering the first error.
+type msgReader struct {
+    buf []byte
+    err error
+}
+
+func (r *msgReader) bytes(n int) []byte {
+    if r.err != nil || n < 0 || n > len(r.buf) {
+        r.err = errShortMessage
+        return nil
+    }
+    b := r.buf[:n]
+    r.buf = r.buf[n:]
+    return b
+}
+
+func (r *msgReader) byte() byte {
+    if b := r.bytes(1); b != nil {
+        return b[0]
+    }
+    return 0
+}
+
+func (r *msgReader) uint16() uint16 {
+    if b := r.bytes(2); b != nil {
+        return binary.BigEndian.Uint16(b)
+    }
+    return 0
+}
+
+func (r *msgReader) uint32() uint32 {
+    if b := r.bytes(4); b != nil {
+        return binary.BigEndian.Uint32(b)
+    }
+    return 0
+}
+
+func (r *msgReader) uint64() uint64 {
+    if b := r.bytes(8); b != nil {
+        return binary.BigEndian.Uint64(b)
+    }
+    return 0
+}
+
+func (r *msgReader) cstring() string {
+    i := bytes.IndexByte(r.buf, 0)
+    if r.err != nil || i < 0 {
+        r.err = errShortMessage
+        return ""
+    }
+    s := string(r.buf[:i])
+    r.buf = r.buf[i+1:]
+    return s
+}
@@ -0,0 +1,67 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+)
+
+// ErrPoolExhausted is wrapped, together with the context error, by the errors
+// of DB methods whose context ended while they waited for a connection
+// because all SetMaxOpenConns connections were in use. It points at the local
+// pool being too small or connections being held too long. A server that
+// refuses new connections fails them instead with a *pgconn.PgError with
+// SQLSTATE 53300 (too_many_connections), which points at the server's
+// max_connections.
+//
+// The Conn, BeginTx, ExecContext, QueryContext and PrepareContext methods
+// report it. QueryRowContext cannot, since sql.Row keeps its error to itself,
+// and returns the bare context error; use QueryContext where the difference
+// matters. The methods without a context wait for a connection indefinitely.
+var ErrPoolExhausted = errors.New("serin: connection pool exhausted")
+
+// Conn is sql.DB.Conn, reporting ErrPoolExhausted.
+func (db *DB) Conn(ctx context.Context) (*sql.Conn, error) {
+    conn, err := db.DB.Conn(ctx)
+    return conn, db.poolError(ctx, err)
+}
+
+// BeginTx is sql.DB.BeginTx, reporting ErrPoolExhausted.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+    tx, err := db.DB.BeginTx(ctx, opts)
+    return tx, db.poolError(ctx, err)
+}
+
+// ExecContext is sql.DB.ExecContext, reporting ErrPoolExhausted.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+    res, err := db.DB.ExecContext(ctx, query, args...)
+    return res, db.poolError(ctx, err)
+}
+
+// QueryContext is sql.DB.QueryContext, reporting ErrPoolExhausted.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+    rows, err := db.DB.QueryContext(ctx, query, args...)
+    return rows, db.poolError(ctx, err)
+}
+
+// PrepareContext is sql.DB.PrepareContext, reporting ErrPoolExhausted.
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+    stmt, err := db.DB.PrepareContext(ctx, query)
+    return stmt, db.poolError(ctx, err)
+}
+
+// poolError wraps err in ErrPoolExhausted when it is the bare context error
+// database/sql returns for a wait for a connection that ended with every
+// connection in use. Errors of running statements come from the driver and
+// are never the bare context error.
+func (db *DB) poolError(ctx context.Context, err error) error {
+    if err == nil || err != ctx.Err() {
+        return err
+    }
+    st := db.Stats()
+    if st.MaxOpenConnections == 0 || st.InUse < st.MaxOpenConnections {
+        return err
+    }
+    return fmt.Errorf("%w (%d of %d connections in use): %w", ErrPoolExhausted, st.InUse, st.MaxOpenConnections, err)
+}
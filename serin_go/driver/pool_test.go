@@ -0,0 +1,89 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "net"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgproto3"
+)
+
+func TestPoolExhausted(t *testing.T) {
+    host, port := noticeServer(t)
+    c, err := NewConnector("host=" + host + " port=" + port + " user=alice sslmode=disable")
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := Wrap(sql.OpenDB(c))
+    defer db.Close()
+    db.SetMaxOpenConns(1)
+
+    held, err := db.Conn(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    wait := func() context.Context {
+        ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+        t.Cleanup(cancel)
+        return ctx
+    }
+    _, err = db.Conn(wait())
+    if !errors.Is(err, ErrPoolExhausted) || !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("Conn: got %v", err)
+    }
+    if _, err := db.QueryContext(wait(), "SELECT progress()", SimpleProtocol); !errors.Is(err, ErrPoolExhausted) {
+        t.Errorf("QueryContext: got %v", err)
+    }
+    if _, err := db.ExecContext(wait(), "SELECT 1", SimpleProtocol); !errors.Is(err, ErrPoolExhausted) {
+        t.Errorf("ExecContext: got %v", err)
+    }
+    if _, err := db.BeginTx(wait(), nil); !errors.Is(err, ErrPoolExhausted) {
+        t.Errorf("BeginTx: got %v", err)
+    }
+    if _, err := db.PrepareContext(wait(), "SELECT 1"); !errors.Is(err, ErrPoolExhausted) {
+        t.Errorf("PrepareContext: got %v", err)
+    }
+    held.Close()
+
+    // A context that ends with connections to spare is not exhaustion.
+    done, cancel := context.WithCancel(context.Background())
+    cancel()
+    if _, err := db.Conn(done); !errors.Is(err, context.Canceled) || errors.Is(err, ErrPoolExhausted) {
+        t.Errorf("canceled with a free pool: got %v", err)
+    }
+    if _, err := db.ExecContext(wait(), "SELECT 1", SimpleProtocol); err != nil {
+        t.Errorf("free pool: %v", err)
+    }
+}
+
+func TestServerTooManyConnections(t *testing.T) {
+    host, port := fakeServer(t, func(c net.Conn) {
+        be := pgproto3.NewBackend(c, c)
+        if _, err := be.ReceiveStartupMessage(); err != nil {
+            return
+        }
+        be.Send(&pgproto3.ErrorResponse{Severity: "FATAL", Code: "53300", Message: "sorry, too many clients already"})
+        be.Flush()
+    })
+    c, err := NewConnector("host=" + host + " port=" + port + " user=alice sslmode=disable")
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := Wrap(sql.OpenDB(c))
+    defer db.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    _, err = db.Conn(ctx)
+    var pgErr *pgconn.PgError
+    if !errors.As(err, &pgErr) || pgErr.Code != "53300" {
+        t.Fatalf("got %v, want SQLSTATE 53300", err)
+    }
+    if errors.Is(err, ErrPoolExhausted) {
+        t.Error("server rejection reported as pool exhaustion")
+    }
+}
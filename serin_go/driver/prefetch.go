@@ -0,0 +1,57 @@
+package driver
+
+import "database/sql/driver"
+
+// prefetchReadSize is the socket read buffer of connections that prefetch
+// rows, so that a batch of rows typically arrives in a few large reads
+// instead of one read per protocol chunk.
+const prefetchReadSize = 64 << 10
+
+// rowBuffer holds rows read ahead of database/sql. Its row slices are reused
+// between batches, so memory stays bounded by the batch size.
+type rowBuffer struct {
+    rows [][]driver.Value
+    n    int   // rows filled in the current batch
+    pos  int   // next row to hand out
+    err  error // error that ended the last batch, returned once it is drained
+}
+
+func newRowBuffer(size, columns int) *rowBuffer {
+    rows := make([][]driver.Value, size)
+    for i := range rows {
+        rows[i] = make([]driver.Value, columns)
+    }
+    return &rowBuffer{rows: rows}
+}
+
+// nextBuffered serves dest from the prefetch buffer, reading the next batch
+// once it is drained. A cancelled context ends iteration immediately, even
+// with rows still buffered.
+func (r *serinRows) nextBuffered(dest []driver.Value) error {
+    b := r.prefetch
+    if err := r.ctx.Err(); err != nil {
+        if !r.failed {
+            r.failed = true
+            return r.conn.failed(r.ctx, err)
+        }
+        return err
+    }
+    if b.pos == b.n {
+        if b.err != nil {
+            return b.err
+        }
+        b.n, b.pos = 0, 0
+        for b.n < len(b.rows) {
+            if b.err = r.read(b.rows[b.n]); b.err != nil {
+                break
+            }
+            b.n++
+        }
+        if b.n == 0 {
+            return b.err
+        }
+    }
+    copy(dest, b.rows[b.pos])
+    b.pos++
+    return nil
+}
@@ -0,0 +1,96 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "testing"
+)
+
+func TestResultBufferRowsParam(t *testing.T) {
+    c, err := NewConnector("host=127.0.0.1 result_buffer_rows=500")
+    if err != nil || c.bufferRows != 500 {
+        t.Fatalf("got %d, %v", c.bufferRows, err)
+    }
+    if _, ok := c.config.RuntimeParams["result_buffer_rows"]; ok {
+        t.Error("result_buffer_rows would be sent to the server")
+    }
+    if _, err := NewConnector("host=127.0.0.1 result_buffer_rows=-1"); err == nil {
+        t.Error("negative result_buffer_rows accepted")
+    }
+}
+
+func TestPrefetchRows(t *testing.T) {
+    db, _ := testConnectorDB(t, WithResultBufferRows(1000))
+    rows, err := db.Query("SELECT g, 'row ' || g FROM generate_series(1, 2500) g")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer rows.Close()
+    want := 1
+    for rows.Next() {
+        var n int
+        var s string
+        if err := rows.Scan(&n, &s); err != nil {
+            t.Fatal(err)
+        }
+        if n != want || s != fmt.Sprintf("row %d", n) {
+            t.Fatalf("got %d %q, want row %d", n, s, want)
+        }
+        want++
+    }
+    if err := rows.Err(); err != nil || want != 2501 {
+        t.Fatalf("stopped at %d: %v", want, err)
+    }
+}
+
+func TestPrefetchRowsCancelled(t *testing.T) {
+    db, _ := testConnectorDB(t, WithResultBufferRows(1000))
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    rows, err := db.QueryContext(ctx, "SELECT g FROM generate_series(1, 100000) g")
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Stop in the middle of the first buffered batch.
+    for i := 0; i < 10 && rows.Next(); i++ {
+    }
+    cancel()
+    n := 0
+    for rows.Next() {
+        n++
+    }
+    if err := rows.Err(); !errors.Is(err, context.Canceled) {
+        t.Errorf("got %v after %d more rows, want context.Canceled", err, n)
+    }
+    rows.Close()
+    var one int
+    if err := db.QueryRow("SELECT 1").Scan(&one); err != nil || one != 1 {
+        t.Errorf("pool unusable after cancel: %v", err)
+    }
+}
+
+func BenchmarkScan1M(b *testing.B) {
+    for _, n := range []int{0, 1000} {
+        b.Run(fmt.Sprintf("buffer=%d", n), func(b *testing.B) {
+            db, _ := testConnectorDB(b, WithResultBufferRows(n))
+            for i := 0; i < b.N; i++ {
+                rows, err := db.Query("SELECT g, g::text FROM generate_series(1, 1000000) g")
+                if err != nil {
+                    b.Fatal(err)
+                }
+                var id int64
+                var s string
+                for rows.Next() {
+                    if err := rows.Scan(&id, &s); err != nil {
+                        b.Fatal(err)
+                    }
+                }
+                if err := rows.Err(); err != nil {
+                    b.Fatal(err)
+                }
+                rows.Close()
+            }
+        })
+    }
+}
@@ -0,0 +1,85 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+)
+
+// JobBatch holds rows claimed by DequeueJob together with the transaction
+// that locks them. Run the follow-up statements (typically deleting or
+// marking the jobs done) on the embedded Tx, then Commit to finish the batch,
+// or Rollback to release the jobs for other workers.
+type JobBatch struct {
+    *sql.Tx
+    // Jobs are the claimed rows, keyed by column name.
+    Jobs []map[string]any
+}
+
+// DequeueJob claims up to limit rows of the job table with SELECT ... FOR
+// UPDATE SKIP LOCKED, so concurrent workers never claim the same row and do
+// not wait for each other's locks. The rows are returned locked by a
+// transaction opened on one pooled connection, which the caller must end with
+// Commit or Rollback on the returned batch, also when Jobs is empty. Until
+// then the connection is not returned to the pool.
+//
+// The locks last exactly as long as the transaction: if the worker crashes,
+// its connection drops or ctx is done before Commit, the transaction is
+// rolled back and the rows become available to other workers again, so jobs
+// are processed at least once. Keep batches short, since an open transaction
+// holds back vacuum on the whole database. Rows are claimed in no particular
+// order.
+//
+//	batch, err := db.DequeueJob(ctx, "jobs", 10)
+//	if err != nil { ... }
+//	defer batch.Rollback()
+//	for _, job := range batch.Jobs { ...; batch.ExecContext(ctx, "DELETE FROM jobs WHERE id = $1", job["id"]) }
+//	err = batch.Commit()
+func (db *DB) DequeueJob(ctx context.Context, table string, limit int) (*JobBatch, error) {
+    if limit <= 0 {
+        return nil, fmt.Errorf("serin: DequeueJob from %s needs a positive limit, got %d", table, limit)
+    }
+    name, err := tableIdent(table)
+    if err != nil {
+        return nil, err
+    }
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    jobs, err := claimJobs(ctx, tx, "SELECT * FROM "+name.Sanitize()+" LIMIT $1 FOR UPDATE SKIP LOCKED", limit)
+    if err != nil {
+        tx.Rollback()
+        return nil, err
+    }
+    return &JobBatch{Tx: tx, Jobs: jobs}, nil
+}
+
+func claimJobs(ctx context.Context, tx *sql.Tx, query string, limit int) ([]map[string]any, error) {
+    rows, err := tx.QueryContext(ctx, query, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    cols, err := rows.Columns()
+    if err != nil {
+        return nil, err
+    }
+    var jobs []map[string]any
+    for rows.Next() {
+        vals := make([]any, len(cols))
+        ptrs := make([]any, len(cols))
+        for i := range vals {
+            ptrs[i] = &vals[i]
+        }
+        if err := rows.Scan(ptrs...); err != nil {
+            return nil, err
+        }
+        job := make(map[string]any, len(cols))
+        for i, col := range cols {
+            job[col] = vals[i]
+        }
+        jobs = append(jobs, job)
+    }
+    return jobs, rows.Err()
+}
@@ -0,0 +1,96 @@
+package driver
+
+import (
+    "context"
+    "sync"
+    "testing"
+)
+
+func TestDequeueJobRejectsBadInput(t *testing.T) {
+    db := Wrap(nil)
+    if _, err := db.DequeueJob(context.Background(), "jobs", 0); err == nil {
+        t.Error("zero limit accepted")
+    }
+    if _, err := db.DequeueJob(context.Background(), "jobs.", 1); err == nil {
+        t.Error("invalid table accepted")
+    }
+}
+
+func TestDequeueJobConcurrentWorkers(t *testing.T) {
+    const jobs, workers = 200, 8
+    db := Wrap(testDB(t))
+    db.SetMaxOpenConns(workers)
+    mustExec(t, db.DB, "DROP TABLE IF EXISTS serin_jobs", "CREATE TABLE serin_jobs (id int8 PRIMARY KEY, payload text)",
+        "INSERT INTO serin_jobs SELECT g, 'job ' || g FROM generate_series(1, 200) g")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_jobs") })
+    ctx := context.Background()
+
+    var mu sync.Mutex
+    claimed := make(map[int64]int)
+    var wg sync.WaitGroup
+    for w := 0; w < workers; w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for {
+                batch, err := db.DequeueJob(ctx, "serin_jobs", 7)
+                if err != nil {
+                    t.Error(err)
+                    return
+                }
+                if len(batch.Jobs) == 0 {
+                    batch.Rollback()
+                    return
+                }
+                for _, job := range batch.Jobs {
+                    id := job["id"].(int64)
+                    if _, err := batch.ExecContext(ctx, "DELETE FROM serin_jobs WHERE id = $1", id); err != nil {
+                        t.Error(err)
+                    }
+                    mu.Lock()
+                    claimed[id]++
+                    mu.Unlock()
+                }
+                if err := batch.Commit(); err != nil {
+                    t.Error(err)
+                    return
+                }
+            }
+        }()
+    }
+    wg.Wait()
+
+    if len(claimed) != jobs {
+        t.Errorf("claimed %d distinct jobs, want %d", len(claimed), jobs)
+    }
+    for id, n := range claimed {
+        if n != 1 {
+            t.Errorf("job %d claimed %d times", id, n)
+        }
+    }
+}
+
+func TestDequeueJobRollbackReleases(t *testing.T) {
+    db := Wrap(testDB(t))
+    mustExec(t, db.DB, "DROP TABLE IF EXISTS serin_jobs_rb", "CREATE TABLE serin_jobs_rb (id int8)", "INSERT INTO serin_jobs_rb VALUES (1)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_jobs_rb") })
+    ctx := context.Background()
+
+    first, err := db.DequeueJob(ctx, "serin_jobs_rb", 5)
+    if err != nil || len(first.Jobs) != 1 || first.Jobs[0]["id"] != int64(1) {
+        t.Fatalf("got %+v, %v", first, err)
+    }
+    second, err := db.DequeueJob(ctx, "serin_jobs_rb", 5)
+    if err != nil || len(second.Jobs) != 0 {
+        t.Fatalf("locked job claimed twice: %+v, %v", second, err)
+    }
+    second.Rollback()
+    if err := first.Rollback(); err != nil {
+        t.Fatal(err)
+    }
+    third, err := db.DequeueJob(ctx, "serin_jobs_rb", 5)
+    if err != nil || len(third.Jobs) != 1 {
+        t.Fatalf("released job not claimable: %+v, %v", third, err)
+    }
+    third.Rollback()
+}
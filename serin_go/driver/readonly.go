@@ -0,0 +1,90 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ErrReadOnlyBackend is returned under WithRejectWritesOnReplica for write
+// statements sent to a server in recovery.
+var ErrReadOnlyBackend = errors.New("serin: server is a read-only replica")
+
+// WithRejectWritesOnReplica makes connections check pg_is_in_recovery() when
+// they are opened and, on a replica, fail write statements with
+// ErrReadOnlyBackend before anything is sent. Writes are recognised by their
+// leading keyword: INSERT, UPDATE, DELETE, MERGE, TRUNCATE, COPY ... FROM,
+// CREATE, ALTER, DROP, GRANT, REVOKE, COMMENT, SECURITY LABEL, REINDEX,
+// VACUUM, ANALYZE, CLUSTER, REFRESH and IMPORT; COPY ... TO exports are
+// allowed. Writes hidden in other statements, such as data-modifying WITH
+// queries, SELECT ... INTO or functions with side effects, are not recognised
+// and still fail on the server. A replica promoted to primary keeps rejecting
+// writes on connections opened before the promotion.
+func WithRejectWritesOnReplica(reject bool) Option {
+    return func(c *Connector) { c.rejectWrites = reject }
+}
+
+var writeKeywords = map[string]bool{
+    "INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "TRUNCATE": true,
+    "CREATE": true, "ALTER": true, "DROP": true, "GRANT": true, "REVOKE": true, "COMMENT": true,
+    "SECURITY": true, "REINDEX": true, "VACUUM": true, "ANALYZE": true, "CLUSTER": true,
+    "REFRESH": true, "IMPORT": true,
+}
+
+// isWrite reports whether query starts with a keyword of a statement that
+// writes to the database. COPY only writes in its FROM form; COPY ... TO
+// exports read the database and are allowed on replicas.
+func isWrite(query string) bool {
+    kw := leadingKeywords(query, 1)
+    if len(kw) == 1 && kw[0] == "COPY" {
+        return copyDirection(query) != "TO"
+    }
+    return len(kw) == 1 && writeKeywords[kw[0]]
+}
+
+// copyDirection returns FROM or TO, whichever follows the table, column list
+// or parenthesised query of the COPY statement query, or "" if neither does.
+func copyDirection(query string) string {
+    s := skipSpaceAndComments(query)[len("COPY"):]
+    depth := 0
+    for s != "" {
+        kind, n := nextToken(s)
+        tok := s[:n]
+        s = s[n:]
+        switch {
+        case tok == "(":
+            depth++
+        case tok == ")":
+            depth--
+        case kind == tokenWord && depth == 0:
+            if w := strings.ToUpper(tok); w == "FROM" || w == "TO" {
+                return w
+            }
+        case kind == tokenSemicolon:
+            return ""
+        }
+    }
+    return ""
+}
+
+// checkRecovery records whether sc is connected to a server in recovery.
+func (c *Connector) checkRecovery(ctx context.Context, sc *serinConn) error {
+    if !c.rejectWrites {
+        return nil
+    }
+    if err := sc.conn.QueryRow(ctx, "SELECT pg_is_in_recovery()", pgx.QueryExecModeSimpleProtocol).Scan(&sc.replica); err != nil {
+        return fmt.Errorf("serin: checking for recovery: %w", err)
+    }
+    return nil
+}
+
+// checkWrite rejects query on a replica if it is a write.
+func (c *serinConn) checkWrite(query string) error {
+    if !c.replica || !isWrite(query) {
+        return nil
+    }
+    return fmt.Errorf("%w: %s", ErrReadOnlyBackend, leadingKeywords(query, 1)[0])
+}
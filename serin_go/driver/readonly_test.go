@@ -0,0 +1,87 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "net"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgproto3"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestIsWrite(t *testing.T) {
+    for _, q := range []string{"INSERT INTO t VALUES (1)", "  update t SET a = 1", "/* c */ DELETE FROM t", "-- x\nCREATE TABLE t (a int)",
+        "drop table t", "TRUNCATE t", "COPY t FROM STDIN", "copy app.t (a, b) from stdin", "COPY t", "COPY to_t FROM STDIN", "GRANT SELECT ON t TO r", "vacuum", "REFRESH MATERIALIZED VIEW v"} {
+        if !isWrite(q) {
+            t.Errorf("%q not classified as a write", q)
+        }
+    }
+    for _, q := range []string{"SELECT 1", "WITH x AS (SELECT 1) SELECT * FROM x", "SHOW TimeZone", "EXPLAIN SELECT 1", "VALUES (1)", "TABLE t", "SET search_path = app", "",
+        "COPY t TO STDOUT", "copy t (a, b) to stdout with (format csv)", "COPY (SELECT a FROM t) TO STDOUT", `COPY "from" TO STDOUT`,
+        "COPY (SELECT * FROM t WHERE a IN (SELECT b FROM u)) TO STDOUT (FORMAT binary)"} {
+        if isWrite(q) {
+            t.Errorf("%q classified as a write", q)
+        }
+    }
+}
+
+// replicaServer fakes a server in recovery. It answers the simple protocol
+// queries sent while connecting and fails the test on any statement sent
+// afterwards.
+func replicaServer(t *testing.T) (host, port string) {
+    return fakeServer(t, func(c net.Conn) {
+        be := pgproto3.NewBackend(c, c)
+        if _, err := be.ReceiveStartupMessage(); err != nil {
+            return
+        }
+        be.Send(&pgproto3.AuthenticationOk{})
+        be.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+        be.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+        be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+        be.Flush()
+        for {
+            msg, err := be.Receive()
+            if err != nil {
+                return
+            }
+            switch msg := msg.(type) {
+            case *pgproto3.Query:
+                if strings.Contains(msg.String, "pg_is_in_recovery") {
+                    be.Send(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{{Name: []byte("pg_is_in_recovery"), DataTypeOID: pgtype.BoolOID, DataTypeSize: 1, TypeModifier: -1}}})
+                    be.Send(&pgproto3.DataRow{Values: [][]byte{[]byte("t")}})
+                    be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")})
+                } else {
+                    be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 0")})
+                }
+                be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+                be.Flush()
+            case *pgproto3.Terminate:
+                return
+            default:
+                t.Errorf("statement sent to the replica: %#v", msg)
+                return
+            }
+        }
+    })
+}
+
+func TestRejectWritesOnReplica(t *testing.T) {
+    host, port := replicaServer(t)
+    c, err := NewConnector("host="+host+" port="+port+" user=alice sslmode=disable", WithRejectWritesOnReplica(true))
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := sql.OpenDB(c)
+    defer db.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    for _, q := range []string{"INSERT INTO t VALUES (1)", "UPDATE t SET a = 2", "DELETE FROM t", "CREATE INDEX ON t (a)"} {
+        if _, err := db.ExecContext(ctx, q); !errors.Is(err, ErrReadOnlyBackend) {
+            t.Errorf("%s: got %v, want ErrReadOnlyBackend", q, err)
+        }
+    }
+}
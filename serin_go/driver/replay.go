@@ -0,0 +1,624 @@
+package driver
+
+import (
+    "context"
+    "database/sql/driver"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "reflect"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNotRecorded is wrapped by the error of running a statement, on a
+// connection opened by ReplayConnector, that has no recorded result left.
+var ErrNotRecorded = errors.New("serin: statement not recorded")
+
+// RecordingConnector returns a connector that opens its connections through c
+// and writes every statement run on them to dst, one JSON object per line:
+// the statement with its whitespace normalized, its arguments, and its
+// columns, rows, command tag and error. Feed the output to ReplayConnector
+// to run the same workload in tests without a server:
+//
+//	f, _ := os.Create("testdata/orders.jsonl")
+//	db := driver.Wrap(sql.OpenDB(driver.RecordingConnector(c, f)))
+//
+// Statements are recorded as they complete, queries when their rows are
+// closed, with the rows read until then. Transactions are passed through but
+// not recorded. The helpers of DB that need the pgx connection, such as
+// Select and CopyFrom, fail on these connections. The first error writing to
+// dst is returned by the connector's Close, that is by sql.DB.Close.
+func RecordingConnector(c driver.Connector, dst io.Writer) driver.Connector {
+    return &recordingConnector{inner: c, enc: json.NewEncoder(dst)}
+}
+
+type recordingConnector struct {
+    inner driver.Connector
+
+    mu  sync.Mutex
+    enc *json.Encoder
+    err error // first error writing a recording
+}
+
+func (c *recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+    conn, err := c.inner.Connect(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return &recordingConn{inner: conn, connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver { return c.inner.Driver() }
+
+func (c *recordingConnector) Close() error {
+    var err error
+    if closer, ok := c.inner.(io.Closer); ok {
+        err = closer.Close()
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.err != nil {
+        return fmt.Errorf("serin: writing recording: %w", c.err)
+    }
+    return err
+}
+
+// write appends r to the recording. Failures on a bad connection are left
+// out: database/sql retries them on another connection.
+func (c *recordingConnector) write(r *recording) {
+    if errors.Is(r.err, driver.ErrBadConn) || errors.Is(r.rowsErr, driver.ErrBadConn) {
+        return
+    }
+    r.Err, r.RowsErr = recordError(r.err), recordError(r.rowsErr)
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if err := c.enc.Encode(r); err != nil && c.err == nil {
+        c.err = err
+    }
+}
+
+// recording is one line of a recording: a statement and what it returned.
+type recording struct {
+    Query        string            `json:"query"`
+    Args         []recordedValue   `json:"args,omitempty"`
+    Columns      []string          `json:"columns,omitempty"`
+    Types        []string          `json:"types,omitempty"`
+    Rows         [][]recordedValue `json:"rows,omitempty"`
+    Tag          string            `json:"tag,omitempty"`
+    RowsAffected int64             `json:"rows_affected,omitempty"`
+    Err          *recordedError    `json:"error,omitempty"`      // returned by Exec or Query
+    RowsErr      *recordedError    `json:"rows_error,omitempty"` // returned by Rows.Next
+
+    err, rowsErr error
+}
+
+func newRecording(query string, args []driver.NamedValue) *recording {
+    r := &recording{Query: normalizeSQL(query)}
+    for _, a := range args {
+        r.Args = append(r.Args, recordValue(a.Value))
+    }
+    return r
+}
+
+// key identifies the statement of r for replay.
+func (r *recording) key() string {
+    args, _ := json.Marshal(r.Args)
+    return r.Query + "\x00" + string(args)
+}
+
+type recordingConn struct {
+    inner     driver.Conn
+    connector *recordingConnector
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+    return c.PrepareContext(context.Background(), query)
+}
+
+func (c *recordingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+    var stmt driver.Stmt
+    var err error
+    if p, ok := c.inner.(driver.ConnPrepareContext); ok {
+        stmt, err = p.PrepareContext(ctx, query)
+    } else {
+        stmt, err = c.inner.Prepare(query)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &recordingStmt{inner: stmt, conn: c, query: query}, nil
+}
+
+func (c *recordingConn) Close() error { return c.inner.Close() }
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+    return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recordingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+    if b, ok := c.inner.(driver.ConnBeginTx); ok {
+        return b.BeginTx(ctx, opts)
+    }
+    return c.inner.Begin()
+}
+
+func (c *recordingConn) ResetSession(ctx context.Context) error {
+    if r, ok := c.inner.(driver.SessionResetter); ok {
+        return r.ResetSession(ctx)
+    }
+    return nil
+}
+
+func (c *recordingConn) IsValid() bool {
+    if v, ok := c.inner.(driver.Validator); ok {
+        return v.IsValid()
+    }
+    return true
+}
+
+func (c *recordingConn) Ping(ctx context.Context) error {
+    if p, ok := c.inner.(driver.Pinger); ok {
+        return p.Ping(ctx)
+    }
+    return nil
+}
+
+func (c *recordingConn) CheckNamedValue(nv *driver.NamedValue) error {
+    if ch, ok := c.inner.(driver.NamedValueChecker); ok {
+        return ch.CheckNamedValue(nv)
+    }
+    return driver.ErrSkip
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+    e, ok := c.inner.(driver.ExecerContext)
+    if !ok {
+        return nil, driver.ErrSkip
+    }
+    res, err := e.ExecContext(ctx, query, args)
+    if errors.Is(err, driver.ErrSkip) {
+        return nil, err
+    }
+    c.connector.write(recordResult(newRecording(query, args), res, err))
+    return res, err
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+    q, ok := c.inner.(driver.QueryerContext)
+    if !ok {
+        return nil, driver.ErrSkip
+    }
+    rows, err := q.QueryContext(ctx, query, args)
+    if errors.Is(err, driver.ErrSkip) {
+        return nil, err
+    }
+    return c.recordRows(newRecording(query, args), rows, err), err
+}
+
+// recordRows records a query once its rows are closed, or right away if it
+// failed.
+func (c *recordingConn) recordRows(r *recording, rows driver.Rows, err error) driver.Rows {
+    if err != nil {
+        r.err = err
+        c.connector.write(r)
+        return nil
+    }
+    r.Columns = rows.Columns()
+    if t, ok := rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+        for i := range r.Columns {
+            r.Types = append(r.Types, t.ColumnTypeDatabaseTypeName(i))
+        }
+    }
+    return &recordingRows{inner: rows, rec: r, conn: c}
+}
+
+// recordResult completes r with the result of an Exec.
+func recordResult(r *recording, res driver.Result, err error) *recording {
+    r.err = err
+    if err != nil {
+        return r
+    }
+    if t, ok := res.(CommandTagger); ok {
+        r.Tag, r.RowsAffected = t.CommandTag()
+    } else if n, err := res.RowsAffected(); err == nil {
+        r.RowsAffected = n
+    }
+    return r
+}
+
+type recordingStmt struct {
+    inner driver.Stmt
+    conn  *recordingConn
+    query string
+}
+
+func (s *recordingStmt) Close() error  { return s.inner.Close() }
+func (s *recordingStmt) NumInput() int { return s.inner.NumInput() }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+    return s.ExecContext(context.Background(), ordinalArgs(args))
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+    return s.QueryContext(context.Background(), ordinalArgs(args))
+}
+
+func (s *recordingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+    e, ok := s.inner.(driver.StmtExecContext)
+    if !ok {
+        return nil, errors.New("serin: recorded statements need StmtExecContext")
+    }
+    res, err := e.ExecContext(ctx, args)
+    s.conn.connector.write(recordResult(newRecording(s.query, args), res, err))
+    return res, err
+}
+
+func (s *recordingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+    q, ok := s.inner.(driver.StmtQueryContext)
+    if !ok {
+        return nil, errors.New("serin: recorded statements need StmtQueryContext")
+    }
+    rows, err := q.QueryContext(ctx, args)
+    return s.conn.recordRows(newRecording(s.query, args), rows, err), err
+}
+
+// ordinalArgs numbers positional driver values as database/sql does.
+func ordinalArgs(args []driver.Value) []driver.NamedValue {
+    out := make([]driver.NamedValue, len(args))
+    for i, a := range args {
+        out[i] = driver.NamedValue{Ordinal: i + 1, Value: a}
+    }
+    return out
+}
+
+type recordingRows struct {
+    inner  driver.Rows
+    rec    *recording
+    conn   *recordingConn
+    closed bool
+}
+
+func (r *recordingRows) Columns() []string { return r.rec.Columns }
+
+func (r *recordingRows) ColumnTypeDatabaseTypeName(index int) string {
+    if index < len(r.rec.Types) {
+        return r.rec.Types[index]
+    }
+    return ""
+}
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+    err := r.inner.Next(dest)
+    switch {
+    case err == nil:
+        row := make([]recordedValue, len(dest))
+        for i, v := range dest {
+            row[i] = recordValue(v)
+        }
+        r.rec.Rows = append(r.rec.Rows, row)
+    case err != io.EOF:
+        r.rec.rowsErr = err
+    }
+    return err
+}
+
+func (r *recordingRows) Close() error {
+    err := r.inner.Close()
+    if r.closed {
+        return err
+    }
+    r.closed = true
+    if t, ok := r.inner.(CommandTagger); ok {
+        r.rec.Tag, r.rec.RowsAffected = t.CommandTag()
+    }
+    r.conn.connector.write(r.rec)
+    return err
+}
+
+func (r *recordingRows) CommandTag() (string, int64) { return r.rec.Tag, r.rec.RowsAffected }
+
+// ReplayConnector returns a connector whose connections answer statements
+// from a recording written by RecordingConnector, without a server.
+// Statements are matched by their text with whitespace normalized and by
+// their arguments; a statement recorded several times is answered with its
+// recordings in order. A statement that was not recorded, or was already
+// answered as many times as it was recorded, fails with an error wrapping
+// ErrNotRecorded that names it. Server errors are replayed as
+// *pgconn.PgError, other errors by their message only.
+//
+// Recorded values come back as the driver returned them, except that values
+// of types other than the database/sql ones, such as uuid, come back as their
+// fmt text. Transactions always succeed.
+func ReplayConnector(src io.Reader) (driver.Connector, error) {
+    c := &replayConnector{recorded: make(map[string][]*recording)}
+    dec := json.NewDecoder(src)
+    for line := 1; ; line++ {
+        r := new(recording)
+        if err := dec.Decode(r); err == io.EOF {
+            return c, nil
+        } else if err != nil {
+            return nil, fmt.Errorf("serin: reading recording %d: %w", line, err)
+        }
+        c.recorded[r.key()] = append(c.recorded[r.key()], r)
+    }
+}
+
+type replayConnector struct {
+    mu       sync.Mutex
+    recorded map[string][]*recording // by key, in recording order
+    replayed map[string]int
+}
+
+func (c *replayConnector) Connect(ctx context.Context) (driver.Conn, error) {
+    return &replayConn{connector: c}, nil
+}
+
+func (c *replayConnector) Driver() driver.Driver { return &serinDriver{} }
+
+// next returns the next recording of the statement query runs with args.
+func (c *replayConnector) next(query string, args []driver.NamedValue) (*recording, error) {
+    want := newRecording(query, args)
+    key := want.key()
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    recorded := c.recorded[key]
+    n := c.replayed[key]
+    if n == len(recorded) {
+        args, _ := json.Marshal(want.Args)
+        if n == 0 {
+            return nil, fmt.Errorf("%w: %q with arguments %s", ErrNotRecorded, want.Query, args)
+        }
+        return nil, fmt.Errorf("%w: %q with arguments %s was recorded %d times", ErrNotRecorded, want.Query, args, n)
+    }
+    if c.replayed == nil {
+        c.replayed = make(map[string]int)
+    }
+    c.replayed[key] = n + 1
+    return recorded[n], nil
+}
+
+type replayConn struct {
+    connector *replayConnector
+}
+
+func (c *replayConn) Prepare(query string) (driver.Stmt, error) {
+    return c.PrepareContext(context.Background(), query)
+}
+
+func (c *replayConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+    if isTxControl(query) {
+        return nil, ErrRawTxControl
+    }
+    return &replayStmt{conn: c, query: query}, nil
+}
+
+func (c *replayConn) Close() error { return nil }
+
+func (c *replayConn) Begin() (driver.Tx, error) { return replayTx{}, nil }
+
+func (c *replayConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+    return replayTx{}, nil
+}
+
+func (c *replayConn) CheckNamedValue(nv *driver.NamedValue) error { return checkNamedValue(nv) }
+
+func (c *replayConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+    r, err := c.connector.next(query, args)
+    if err != nil {
+        return nil, err
+    }
+    if r.Err != nil {
+        return nil, r.Err.error()
+    }
+    if r.Tag == "" {
+        return driver.RowsAffected(r.RowsAffected), nil
+    }
+    return commandResult{tag: pgconn.NewCommandTag(r.Tag)}, nil
+}
+
+func (c *replayConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+    r, err := c.connector.next(query, args)
+    if err != nil {
+        return nil, err
+    }
+    if r.Err != nil {
+        return nil, r.Err.error()
+    }
+    return &replayRows{rec: r}, nil
+}
+
+type replayTx struct{}
+
+func (replayTx) Commit() error   { return nil }
+func (replayTx) Rollback() error { return nil }
+
+type replayStmt struct {
+    conn  *replayConn
+    query string
+}
+
+func (s *replayStmt) Close() error  { return nil }
+func (s *replayStmt) NumInput() int { return -1 }
+
+func (s *replayStmt) Exec(args []driver.Value) (driver.Result, error) {
+    return s.ExecContext(context.Background(), ordinalArgs(args))
+}
+
+func (s *replayStmt) Query(args []driver.Value) (driver.Rows, error) {
+    return s.QueryContext(context.Background(), ordinalArgs(args))
+}
+
+func (s *replayStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+    return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *replayStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+    return s.conn.QueryContext(ctx, s.query, args)
+}
+
+type replayRows struct {
+    rec *recording
+    n   int // rows returned so far
+}
+
+func (r *replayRows) Columns() []string { return r.rec.Columns }
+
+func (r *replayRows) ColumnTypeDatabaseTypeName(index int) string {
+    if index < len(r.rec.Types) {
+        return r.rec.Types[index]
+    }
+    return ""
+}
+
+func (r *replayRows) Next(dest []driver.Value) error {
+    if r.n == len(r.rec.Rows) {
+        if r.rec.RowsErr != nil {
+            return r.rec.RowsErr.error()
+        }
+        return io.EOF
+    }
+    row := r.rec.Rows[r.n]
+    r.n++
+    for i := range dest {
+        v, err := row[i].value()
+        if err != nil {
+            return err
+        }
+        dest[i] = v
+    }
+    return nil
+}
+
+func (r *replayRows) Close() error { return nil }
+
+func (r *replayRows) CommandTag() (string, int64) { return r.rec.Tag, r.rec.RowsAffected }
+
+// recordedError is an error in a recording; PgError is set for server errors.
+type recordedError struct {
+    Message string          `json:"message"`
+    PgError *pgconn.PgError `json:"pg_error,omitempty"`
+}
+
+func recordError(err error) *recordedError {
+    if err == nil {
+        return nil
+    }
+    r := &recordedError{Message: err.Error()}
+    errors.As(err, &r.PgError)
+    return r
+}
+
+func (e *recordedError) error() error {
+    if e.PgError != nil {
+        pgErr := *e.PgError
+        return &pgErr
+    }
+    return errors.New(e.Message)
+}
+
+// recordedValue is a driver value in a recording, tagged with its type so
+// that it is replayed as the same Go type.
+type recordedValue struct {
+    Type  string          `json:"type"`
+    Value json.RawMessage `json:"value,omitempty"`
+}
+
+func recordValue(v any) recordedValue {
+    tagged := func(typ string, v any) recordedValue {
+        b, _ := json.Marshal(v)
+        return recordedValue{Type: typ, Value: b}
+    }
+    switch v := v.(type) {
+    case nil:
+        return recordedValue{Type: "null"}
+    case string:
+        return tagged("string", v)
+    case []byte:
+        return tagged("bytes", v)
+    case bool:
+        return tagged("bool", v)
+    case time.Time:
+        return tagged("time", v.Format(time.RFC3339Nano))
+    case float64:
+        return tagged("float", strconv.FormatFloat(v, 'g', -1, 64))
+    case float32:
+        return tagged("float", strconv.FormatFloat(float64(v), 'g', -1, 32))
+    case driver.Valuer:
+        if dv, err := v.Value(); err == nil {
+            if _, ok := dv.(driver.Valuer); !ok {
+                return recordValue(dv)
+            }
+        }
+    }
+    rv := reflect.ValueOf(v)
+    switch rv.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return tagged("int", rv.Int())
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return tagged("uint", rv.Uint())
+    }
+    return tagged("string", fmt.Sprint(v))
+}
+
+func (v recordedValue) value() (driver.Value, error) {
+    var err error
+    switch v.Type {
+    case "null":
+        return nil, nil
+    case "string":
+        var s string
+        err = json.Unmarshal(v.Value, &s)
+        return s, err
+    case "bytes":
+        var b []byte
+        err = json.Unmarshal(v.Value, &b)
+        return b, err
+    case "bool":
+        var b bool
+        err = json.Unmarshal(v.Value, &b)
+        return b, err
+    case "int":
+        var n int64
+        err = json.Unmarshal(v.Value, &n)
+        return n, err
+    case "uint":
+        var n uint64
+        err = json.Unmarshal(v.Value, &n)
+        return n, err
+    case "float", "time":
+        var s string
+        if err := json.Unmarshal(v.Value, &s); err != nil {
+            return nil, err
+        }
+        if v.Type == "time" {
+            return time.Parse(time.RFC3339Nano, s)
+        }
+        return strconv.ParseFloat(s, 64)
+    }
+    return nil, fmt.Errorf("serin: unknown recorded value type %q", v.Type)
+}
+
+// normalizeSQL collapses every run of whitespace outside literals, quoted
+// identifiers and comments to one space and trims the ends, so that
+// recordings match statements that differ only in layout.
+func normalizeSQL(query string) string {
+    var b strings.Builder
+    for s := query; s != ""; {
+        kind, n := nextToken(s)
+        if kind == tokenSpace {
+            if n < len(s) && b.Len() > 0 {
+                b.WriteByte(' ')
+            }
+        } else {
+            b.WriteString(s[:n])
+        }
+        s = s[n:]
+    }
+    return b.String()
+}
@@ -0,0 +1,178 @@
+package driver
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "io"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// stubConnector answers a fixed workload in memory, standing in for a server
+// while recording: inserting the same id twice is a unique violation, and
+// any query returns one row of every recorded value type.
+type stubConnector struct {
+    ids map[any]bool
+}
+
+func (c *stubConnector) Connect(context.Context) (driver.Conn, error) { return &stubConn{c}, nil }
+func (c *stubConnector) Driver() driver.Driver                        { return &serinDriver{} }
+
+type stubConn struct{ connector *stubConnector }
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *stubConn) Close() error                        { return nil }
+func (c *stubConn) Begin() (driver.Tx, error)           { return replayTx{}, nil }
+
+func (c *stubConn) ExecContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Result, error) {
+    if c.connector.ids[args[0].Value] {
+        return nil, &pgconn.PgError{Severity: "ERROR", Code: "23505", Message: "duplicate key value violates unique constraint"}
+    }
+    c.connector.ids[args[0].Value] = true
+    return commandResult{tag: pgconn.NewCommandTag("INSERT 0 1")}, nil
+}
+
+func (c *stubConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+    at := time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC)
+    return &stubRows{row: []driver.Value{int64(7), "seven", nil, at, []byte{0, 0xff}, 0.5, true, "{1,2,3}"}}, nil
+}
+
+type stubRows struct {
+    row  []driver.Value
+    done bool
+}
+
+func (r *stubRows) Columns() []string {
+    return []string{"id", "name", "note", "at", "blob", "ratio", "ok", "tags"}
+}
+
+func (r *stubRows) Close() error                { return nil }
+func (r *stubRows) CommandTag() (string, int64) { return "SELECT 1", 1 }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+    if r.done {
+        return io.EOF
+    }
+    r.done = true
+    copy(dest, r.row)
+    return nil
+}
+
+type replayRow struct {
+    id    int64
+    name  string
+    note  sql.NullString
+    at    time.Time
+    blob  []byte
+    ratio float64
+    ok    bool
+    tags  []int32
+}
+
+// replayWorkload runs the workload recorded and replayed by TestRecordReplay,
+// with layouts of the query text that differ between the two runs.
+func replayWorkload(t *testing.T, db *sql.DB, layout func(string) string) (insertErr error, got replayRow) {
+    t.Helper()
+    if _, err := db.Exec(layout("INSERT INTO items VALUES ($1)"), 7); err != nil {
+        t.Fatal(err)
+    }
+    _, insertErr = db.Exec(layout("INSERT INTO items VALUES ($1)"), 7)
+    err := db.QueryRow(layout("SELECT id, name, note, at, blob, ratio, ok, tags FROM items WHERE id = $1"), 7).
+        Scan(&got.id, &got.name, &got.note, &got.at, &got.blob, &got.ratio, &got.ok, Array(&got.tags))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return insertErr, got
+}
+
+func TestRecordReplay(t *testing.T) {
+    var recorded bytes.Buffer
+    db := sql.OpenDB(RecordingConnector(&stubConnector{ids: map[any]bool{}}, &recorded))
+    recErr, recRow := replayWorkload(t, db, func(q string) string { return q })
+    if err := db.Close(); err != nil {
+        t.Fatal(err)
+    }
+    if n := strings.Count(recorded.String(), "\n"); n != 3 {
+        t.Fatalf("recorded %d statements:\n%s", n, recorded.String())
+    }
+
+    c, err := ReplayConnector(bytes.NewReader(recorded.Bytes()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    db = sql.OpenDB(c)
+    defer db.Close()
+    gotErr, gotRow := replayWorkload(t, db, func(q string) string {
+        return "\n  " + strings.ReplaceAll(q, " ", "\n\t ") + " \n"
+    })
+    if !reflect.DeepEqual(gotRow, recRow) {
+        t.Errorf("replayed row %+v, recorded %+v", gotRow, recRow)
+    }
+    var pgErr *pgconn.PgError
+    if !errors.As(recErr, &pgErr) || !errors.As(gotErr, &pgErr) || pgErr.Code != "23505" {
+        t.Errorf("replayed error %v, recorded %v", gotErr, recErr)
+    }
+
+    if _, err := db.Exec("INSERT INTO items VALUES ($1)", 7); !errors.Is(err, ErrNotRecorded) || !strings.Contains(err.Error(), "recorded 2 times") {
+        t.Errorf("third insert: got %v", err)
+    }
+    if _, err := db.Exec("INSERT INTO items VALUES ($1)", 8); !errors.Is(err, ErrNotRecorded) || !strings.Contains(err.Error(), `"INSERT INTO items VALUES ($1)"`) {
+        t.Errorf("other arguments: got %v", err)
+    }
+    if _, err := db.Query("SELECT 'a  b'"); !errors.Is(err, ErrNotRecorded) {
+        t.Errorf("unrecorded query: got %v", err)
+    }
+}
+
+func TestReplayConnectorBadRecording(t *testing.T) {
+    if _, err := ReplayConnector(strings.NewReader("{\"query\": \"SELECT 1\"}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "recording 2") {
+        t.Errorf("got %v", err)
+    }
+}
+
+func TestNormalizeSQL(t *testing.T) {
+    for in, want := range map[string]string{
+        "  SELECT\n\t1  ":                     "SELECT 1",
+        "SELECT 'a  b',  \"x  y\"\nFROM t":    `SELECT 'a  b', "x  y" FROM t`,
+        "SELECT $$ \n $$ /* a  b */  -- c  d": "SELECT $$ \n $$ /* a  b */ -- c  d",
+        "":                                    "",
+    } {
+        if got := normalizeSQL(in); got != want {
+            t.Errorf("normalizeSQL(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
+
+func TestRecordReplayServer(t *testing.T) {
+    c, err := NewConnector(testDSN(t))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var recorded bytes.Buffer
+    sum := func(db *sql.DB) (n int64) {
+        if err := db.QueryRow("SELECT sum(i) FROM generate_series(1, $1::int) AS i", 10).Scan(&n); err != nil {
+            t.Fatal(err)
+        }
+        return n
+    }
+    db := sql.OpenDB(RecordingConnector(c, &recorded))
+    want := sum(db)
+    db.Close()
+
+    replay, err := ReplayConnector(&recorded)
+    if err != nil {
+        t.Fatal(err)
+    }
+    db = sql.OpenDB(replay)
+    defer db.Close()
+    if got := sum(db); got != want || want != 55 {
+        t.Errorf("replayed %d, recorded %d", got, want)
+    }
+}
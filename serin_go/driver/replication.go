@@ -0,0 +1,321 @@
+package driver
+
+import (
+    "context"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgproto3"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// LSN is a position in the server's write-ahead log.
+type LSN uint64
+
+func (l LSN) String() string { return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l)) }
+
+// ParseLSN parses the textual X/X form of an LSN, as returned by
+// pg_current_wal_lsn.
+func ParseLSN(s string) (LSN, error) {
+    var hi, lo uint32
+    if _, err := fmt.Sscanf(s, "%X/%X", &hi, &lo); err != nil {
+        return 0, fmt.Errorf("serin: invalid LSN %q", s)
+    }
+    return LSN(hi)<<32 | LSN(lo), nil
+}
+
+// ChangeKind is the kind of row change in a ChangeEvent.
+type ChangeKind int
+
+const (
+    ChangeInsert ChangeKind = iota + 1
+    ChangeUpdate
+    ChangeDelete
+)
+
+func (k ChangeKind) String() string {
+    switch k {
+    case ChangeInsert:
+        return "insert"
+    case ChangeUpdate:
+        return "update"
+    case ChangeDelete:
+        return "delete"
+    }
+    return "unknown"
+}
+
+// ChangeEvent is a row change decoded from a logical replication stream.
+// Values are decoded with the default type map from their column types.
+type ChangeEvent struct {
+    Kind   ChangeKind
+    LSN    LSN // WAL position of the change; pass it to Replication.Ack once handled
+    Schema string
+    Table  string
+    // Old holds the replica identity columns of updated and deleted rows, or
+    // the whole row with REPLICA IDENTITY FULL. It is nil for inserts and for
+    // updates that did not change the identity.
+    Old map[string]any
+    // New holds the row written by inserts and updates. Unchanged TOASTed
+    // columns are not sent by the server and are missing from the map.
+    New map[string]any
+}
+
+// ReplicationOptions selects what StartReplication streams.
+type ReplicationOptions struct {
+    Slot        string // existing logical replication slot using the pgoutput plugin
+    Publication string
+    // StartLSN is where to start streaming. Zero resumes after the position
+    // last acknowledged on the slot.
+    StartLSN LSN
+    // StatusInterval is how often the acknowledged position is reported to
+    // the server. The default is 10 seconds.
+    StatusInterval time.Duration
+}
+
+// Replication is a running logical replication stream opened by
+// Connector.StartReplication.
+type Replication struct {
+    conn     *pgconn.PgConn
+    events   chan ChangeEvent
+    interval time.Duration
+    cancel   context.CancelFunc
+    done     chan struct{}
+
+    acked     atomic.Uint64 // highest LSN passed to Ack
+    delivered LSN           // LSN of the last event queued on events
+    received  LSN           // position up to which the server has sent everything
+    written   LSN           // end of the last WAL data received
+
+    errMu sync.Mutex
+    err   error
+}
+
+// StartReplication opens a dedicated replication connection with the
+// connector's settings and starts streaming changes of opts.Publication from
+// opts.Slot. Events are delivered on Replication.Events in commit order; the
+// server keeps WAL for the slot until the changes are acknowledged with
+// Replication.Ack, so delivery is at least once across restarts. Read events
+// promptly: keepalives are only answered while the stream is not blocked on a
+// full channel.
+func (c *Connector) StartReplication(ctx context.Context, opts ReplicationOptions) (*Replication, error) {
+    slot, err := Ident(opts.Slot)
+    if err != nil {
+        return nil, err
+    }
+    if opts.Publication == "" || opts.StatusInterval < 0 {
+        return nil, errors.New("serin: replication needs a publication and a non-negative status interval")
+    }
+    cfg := c.config.Copy().Config.Copy()
+    cfg.RuntimeParams["replication"] = "database"
+    conn, err := pgconn.ConnectConfig(ctx, cfg)
+    if err != nil {
+        return nil, err
+    }
+    query := fmt.Sprintf("START_REPLICATION SLOT %s LOGICAL %s (proto_version '1', publication_names %s)", slot, opts.StartLSN, quoteLiteral(opts.Publication))
+    if err := startCopyBoth(ctx, conn, query); err != nil {
+        conn.Close(ctx)
+        return nil, err
+    }
+    interval := opts.StatusInterval
+    if interval == 0 {
+        interval = 10 * time.Second
+    }
+    runCtx, cancel := context.WithCancel(context.Background())
+    r := &Replication{conn: conn, events: make(chan ChangeEvent, 64), interval: interval, cancel: cancel, done: make(chan struct{})}
+    r.acked.Store(uint64(opts.StartLSN))
+    r.received = opts.StartLSN
+    go r.run(runCtx)
+    return r, nil
+}
+
+// startCopyBoth sends a replication command and waits for the server to
+// switch the connection into streaming mode.
+func startCopyBoth(ctx context.Context, conn *pgconn.PgConn, query string) error {
+    conn.Frontend().Send(&pgproto3.Query{String: query})
+    if err := conn.Frontend().Flush(); err != nil {
+        return err
+    }
+    for {
+        msg, err := conn.ReceiveMessage(ctx)
+        if err != nil {
+            return err
+        }
+        switch msg := msg.(type) {
+        case *pgproto3.CopyBothResponse:
+            return nil
+        case *pgproto3.ErrorResponse:
+            return pgconn.ErrorResponseToPgError(msg)
+        }
+    }
+}
+
+// Events returns the change events. The channel is closed when the stream
+// ends; Err then reports why.
+func (r *Replication) Events() <-chan ChangeEvent { return r.events }
+
+// Ack records that every change up to and including lsn has been handled.
+// The position is reported to the server with the next status update.
+func (r *Replication) Ack(lsn LSN) {
+    for {
+        cur := r.acked.Load()
+        if uint64(lsn) <= cur || r.acked.CompareAndSwap(cur, uint64(lsn)) {
+            return
+        }
+    }
+}
+
+// Err returns the error that ended the stream, or nil if it is still running
+// or was closed.
+func (r *Replication) Err() error {
+    r.errMu.Lock()
+    defer r.errMu.Unlock()
+    return r.err
+}
+
+// Close stops the stream, reporting the acknowledged position first, and
+// closes its connection.
+func (r *Replication) Close() error {
+    r.cancel()
+    <-r.done
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    err := r.sendStatus()
+    if cerr := r.conn.Close(ctx); err == nil {
+        err = cerr
+    }
+    return err
+}
+
+func (r *Replication) fail(err error) {
+    r.errMu.Lock()
+    defer r.errMu.Unlock()
+    r.err = err
+}
+
+func (r *Replication) run(ctx context.Context) {
+    defer close(r.done)
+    defer close(r.events)
+    m := pgtype.NewMap()
+    registerSystemTypes(m)
+    dec := newPgoutputDecoder(m)
+    next := time.Now().Add(r.interval)
+    for {
+        if !time.Now().Before(next) {
+            if err := r.sendStatus(); err != nil {
+                r.fail(err)
+                return
+            }
+            next = time.Now().Add(r.interval)
+        }
+        recvCtx, cancel := context.WithDeadline(ctx, next)
+        msg, err := r.conn.ReceiveMessage(recvCtx)
+        cancel()
+        if ctx.Err() != nil {
+            return
+        }
+        if err != nil {
+            if pgconn.Timeout(err) {
+                continue
+            }
+            r.fail(err)
+            return
+        }
+        switch msg := msg.(type) {
+        case *pgproto3.CopyData:
+            if err := r.handle(ctx, dec, msg.Data); err != nil {
+                if ctx.Err() == nil {
+                    r.fail(err)
+                }
+                return
+            }
+        case *pgproto3.ErrorResponse:
+            r.fail(pgconn.ErrorResponseToPgError(msg))
+            return
+        case *pgproto3.CopyDone:
+            r.fail(errors.New("serin: server ended the replication stream"))
+            return
+        }
+    }
+}
+
+// handle processes one CopyData payload of the replication protocol.
+func (r *Replication) handle(ctx context.Context, dec *pgoutputDecoder, data []byte) error {
+    if len(data) == 0 {
+        return errShortMessage
+    }
+    m := &msgReader{buf: data[1:]}
+    switch data[0] {
+    case 'k': // primary keepalive
+        end := LSN(m.uint64())
+        m.uint64() // server clock
+        reply := m.byte()
+        if m.err != nil {
+            return m.err
+        }
+        if end > r.received {
+            r.received = end
+        }
+        if reply == 1 {
+            return r.sendStatus()
+        }
+    case 'w': // WAL data
+        start := LSN(m.uint64())
+        end := LSN(m.uint64())
+        m.uint64() // server clock
+        if m.err != nil {
+            return m.err
+        }
+        if end > r.written {
+            r.written = end
+        }
+        ev, commitEnd, err := dec.decode(m.buf)
+        if err != nil {
+            return err
+        }
+        if commitEnd > r.received {
+            r.received = commitEnd
+        }
+        if ev != nil {
+            ev.LSN = start
+            select {
+            case r.events <- *ev:
+                r.delivered = start
+            case <-ctx.Done():
+                return ctx.Err()
+            }
+        }
+    }
+    return nil
+}
+
+// sendStatus reports the received and acknowledged positions. Once every
+// delivered event is acknowledged, positions the server has fully sent are
+// confirmed too, so transactions without matching changes do not hold WAL.
+func (r *Replication) sendStatus() error {
+    flushed := LSN(r.acked.Load())
+    if flushed >= r.delivered && r.received > flushed {
+        flushed = r.received
+    }
+    written := max(r.written, flushed)
+    buf := make([]byte, 0, 34)
+    buf = append(buf, 'r')
+    buf = binary.BigEndian.AppendUint64(buf, uint64(written))
+    buf = binary.BigEndian.AppendUint64(buf, uint64(flushed))
+    buf = binary.BigEndian.AppendUint64(buf, uint64(flushed))
+    buf = binary.BigEndian.AppendUint64(buf, uint64(pgEpochMicros(time.Now())))
+    buf = append(buf, 0) // no reply requested
+    r.conn.Frontend().Send(&pgproto3.CopyData{Data: buf})
+    return r.conn.Frontend().Flush()
+}
+
+// pgEpochMicros returns t as microseconds since 2000-01-01, the server's
+// timestamp epoch.
+func pgEpochMicros(t time.Time) int64 {
+    return t.Sub(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).Microseconds()
+}
@@ -0,0 +1,241 @@
+package driver
+
+import (
+    "context"
+    "encoding/binary"
+    "net"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgproto3"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestLSN(t *testing.T) {
+    lsn, err := ParseLSN("16/B374D848")
+    if err != nil || lsn != 0x16B374D848 || lsn.String() != "16/B374D848" {
+        t.Errorf("got %v (%d), %v", lsn, uint64(lsn), err)
+    }
+    if _, err := ParseLSN("nope"); err == nil {
+        t.Error("invalid LSN accepted")
+    }
+}
+
+// pgoutputMsg builds a pgoutput message from bytes, strings (NUL terminated)
+// and big-endian integers.
+func pgoutputMsg(parts ...any) []byte {
+    var b []byte
+    for _, p := range parts {
+        switch p := p.(type) {
+        case byte:
+            b = append(b, p)
+        case string:
+            b = append(append(b, p...), 0)
+        case uint16:
+            b = binary.BigEndian.AppendUint16(b, p)
+        case uint32:
+            b = binary.BigEndian.AppendUint32(b, p)
+        case uint64:
+            b = binary.BigEndian.AppendUint64(b, p)
+        case []byte:
+            b = append(binary.BigEndian.AppendUint32(b, uint32(len(p))), p...)
+        }
+    }
+    return b
+}
+
+func TestPgoutputDecoder(t *testing.T) {
+    d := newPgoutputDecoder(pgtype.NewMap())
+    rel := pgoutputMsg(byte('R'), uint32(42), "public", "items", byte('d'), uint16(3),
+        byte(1), "id", uint32(pgtype.Int8OID), uint32(0xffffffff),
+        byte(0), "name", uint32(pgtype.TextOID), uint32(0xffffffff),
+        byte(0), "doc", uint32(pgtype.TextOID), uint32(0xffffffff))
+    if ev, _, err := d.decode(rel); ev != nil || err != nil {
+        t.Fatalf("relation: %v, %v", ev, err)
+    }
+
+    ev, _, err := d.decode(pgoutputMsg(byte('I'), uint32(42), byte('N'), uint16(3), byte('t'), []byte("7"), byte('t'), []byte("o'hara"), byte('n')))
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := &ChangeEvent{Kind: ChangeInsert, Schema: "public", Table: "items", New: map[string]any{"id": int64(7), "name": "o'hara", "doc": nil}}
+    if !reflect.DeepEqual(ev, want) {
+        t.Errorf("insert: got %+v\nwant %+v", ev, want)
+    }
+
+    ev, _, err = d.decode(pgoutputMsg(byte('U'), uint32(42), byte('K'), uint16(1), byte('t'), []byte("7"), byte('N'), uint16(3), byte('t'), []byte("8"), byte('t'), []byte(""), byte('u')))
+    if err != nil {
+        t.Fatal(err)
+    }
+    want = &ChangeEvent{Kind: ChangeUpdate, Schema: "public", Table: "items", Old: map[string]any{"id": int64(7)}, New: map[string]any{"id": int64(8), "name": ""}}
+    if !reflect.DeepEqual(ev, want) {
+        t.Errorf("update: got %+v\nwant %+v", ev, want)
+    }
+
+    ev, _, err = d.decode(pgoutputMsg(byte('D'), uint32(42), byte('K'), uint16(1), byte('t'), []byte("8")))
+    if err != nil || ev.Kind != ChangeDelete || ev.Old["id"] != int64(8) || ev.New != nil {
+        t.Errorf("delete: got %+v, %v", ev, err)
+    }
+
+    ev, end, err := d.decode(pgoutputMsg(byte('C'), byte(0), uint64(100), uint64(120), uint64(0)))
+    if ev != nil || end != 120 || err != nil {
+        t.Errorf("commit: got %v, %v, %v", ev, end, err)
+    }
+
+    if _, _, err := d.decode(pgoutputMsg(byte('I'), uint32(99), byte('N'), uint16(0))); err == nil {
+        t.Error("change for unknown relation accepted")
+    }
+    if _, _, err := d.decode(pgoutputMsg(byte('I'), uint32(42), byte('N'), uint16(1), byte('t'))); err == nil {
+        t.Error("truncated tuple accepted")
+    }
+}
+
+// walSender fakes a server streaming one inserted row at LSN 0x100 and
+// reports the flushed positions of the status updates it receives.
+func walSender(t *testing.T, flushed chan<- LSN) (host, port string) {
+    return fakeServer(t, func(c net.Conn) {
+        be := pgproto3.NewBackend(c, c)
+        msg, err := be.ReceiveStartupMessage()
+        if err != nil {
+            return
+        }
+        if sm, ok := msg.(*pgproto3.StartupMessage); !ok || sm.Parameters["replication"] != "database" {
+            t.Errorf("not a replication connection: %#v", msg)
+            return
+        }
+        be.Send(&pgproto3.AuthenticationOk{})
+        be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+        be.Flush()
+        if msg, err := be.Receive(); err != nil || !strings.HasPrefix(msg.(*pgproto3.Query).String, `START_REPLICATION SLOT "s" LOGICAL 0/0 (proto_version '1', publication_names 'p')`) {
+            t.Errorf("unexpected command %#v, %v", msg, err)
+            return
+        }
+        be.Send(&pgproto3.CopyBothResponse{})
+        wal := func(at uint64, data []byte) {
+            be.Send(&pgproto3.CopyData{Data: append(pgoutputMsg(byte('w'), at, at, uint64(0)), data...)})
+        }
+        wal(0x100, pgoutputMsg(byte('R'), uint32(1), "public", "t", byte('d'), uint16(1), byte(1), "id", uint32(pgtype.Int4OID), uint32(0)))
+        wal(0x100, pgoutputMsg(byte('I'), uint32(1), byte('N'), uint16(1), byte('t'), []byte("1")))
+        wal(0x180, pgoutputMsg(byte('C'), byte(0), uint64(0x100), uint64(0x180), uint64(0)))
+        be.Send(&pgproto3.CopyData{Data: pgoutputMsg(byte('k'), uint64(0x200), uint64(0), byte(1))})
+        be.Flush()
+        for {
+            msg, err := be.Receive()
+            if err != nil {
+                return
+            }
+            if cd, ok := msg.(*pgproto3.CopyData); ok && len(cd.Data) == 34 && cd.Data[0] == 'r' {
+                flushed <- LSN(binary.BigEndian.Uint64(cd.Data[9:]))
+            }
+        }
+    })
+}
+
+func TestReplicationStream(t *testing.T) {
+    flushed := make(chan LSN, 100)
+    host, port := walSender(t, flushed)
+    c, err := NewConnector("host=" + host + " port=" + port + " user=alice sslmode=disable")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    repl, err := c.StartReplication(ctx, ReplicationOptions{Slot: "s", Publication: "p", StatusInterval: 20 * time.Millisecond})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer repl.Close()
+
+    ev := <-repl.Events()
+    if ev.Kind != ChangeInsert || ev.LSN != 0x100 || ev.Table != "t" || ev.New["id"] != int32(1) {
+        t.Fatalf("unexpected event %+v", ev)
+    }
+    // Nothing is confirmed before the event is acknowledged.
+    quiet := time.After(100 * time.Millisecond)
+wait:
+    for {
+        select {
+        case lsn := <-flushed:
+            if lsn != 0 {
+                t.Fatalf("confirmed %s before the event was acknowledged", lsn)
+            }
+        case <-quiet:
+            break wait
+        }
+    }
+    repl.Ack(ev.LSN)
+    // Once acknowledged, the keepalive position past the commit is confirmed.
+    for {
+        select {
+        case lsn := <-flushed:
+            if lsn == 0x200 {
+                return
+            }
+        case <-ctx.Done():
+            t.Fatal("acknowledged position never reported")
+        }
+    }
+}
+
+func TestReplication(t *testing.T) {
+    db, c := testConnectorDB(t)
+    mustExec(t, db,
+        "DROP PUBLICATION IF EXISTS serin_pub",
+        "DROP TABLE IF EXISTS serin_cdc",
+        "CREATE TABLE serin_cdc (id int8 PRIMARY KEY, name text)",
+        "CREATE PUBLICATION serin_pub FOR TABLE serin_cdc")
+    if _, err := db.Exec("SELECT pg_create_logical_replication_slot('serin_slot', 'pgoutput')"); err != nil {
+        t.Skipf("logical replication unavailable: %v", err)
+    }
+    t.Cleanup(func() {
+        db.Exec("SELECT pg_drop_replication_slot('serin_slot')")
+        db.Exec("DROP PUBLICATION serin_pub")
+        db.Exec("DROP TABLE serin_cdc")
+    })
+
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+    repl, err := c.StartReplication(ctx, ReplicationOptions{Slot: "serin_slot", Publication: "serin_pub", StatusInterval: 100 * time.Millisecond})
+    if err != nil {
+        t.Fatal(err)
+    }
+    mustExec(t, db,
+        "INSERT INTO serin_cdc VALUES (1, 'a')",
+        "UPDATE serin_cdc SET name = 'b' WHERE id = 1",
+        "DELETE FROM serin_cdc WHERE id = 1")
+
+    var kinds []ChangeKind
+    var last LSN
+    for len(kinds) < 3 {
+        select {
+        case ev, ok := <-repl.Events():
+            if !ok {
+                t.Fatalf("stream ended: %v", repl.Err())
+            }
+            if ev.Table != "serin_cdc" || ev.Old == nil && ev.New == nil {
+                t.Errorf("unexpected event %+v", ev)
+            }
+            kinds = append(kinds, ev.Kind)
+            last = ev.LSN
+            repl.Ack(ev.LSN)
+        case <-ctx.Done():
+            t.Fatalf("got %v before timeout", kinds)
+        }
+    }
+    if !reflect.DeepEqual(kinds, []ChangeKind{ChangeInsert, ChangeUpdate, ChangeDelete}) {
+        t.Errorf("got %v", kinds)
+    }
+    if err := repl.Close(); err != nil {
+        t.Fatal(err)
+    }
+
+    var confirmed string
+    if err := db.QueryRow("SELECT confirmed_flush_lsn::text FROM pg_replication_slots WHERE slot_name = 'serin_slot'").Scan(&confirmed); err != nil {
+        t.Fatal(err)
+    }
+    if lsn, err := ParseLSN(confirmed); err != nil || lsn < last {
+        t.Errorf("slot confirmed %s, acknowledged %s", confirmed, last)
+    }
+}
@@ -0,0 +1,82 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "math/rand"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// txAttempts is how many times RunInTx runs a transaction that keeps failing
+// with a retryable error.
+const txAttempts = 5
+
+// WithDeadlockHook calls fn for every statement the server aborted to break
+// a deadlock (SQLSTATE 40P01), with the statement text and the server error,
+// whose Detail names the processes and locks involved. fn runs on the
+// goroutine that ran the statement and must not use its connection.
+func WithDeadlockHook(fn func(ctx context.Context, query string, err *pgconn.PgError)) Option {
+    return func(c *Connector) { c.deadlockHook = fn }
+}
+
+// queryFailed is failed for errors of running query, reporting deadlocks to
+// the deadlock hook.
+func (c *serinConn) queryFailed(ctx context.Context, query string, err error) error {
+    var pgErr *pgconn.PgError
+    if c.connector.deadlockHook != nil && errors.As(err, &pgErr) && pgErr.Code == "40P01" {
+        c.connector.deadlockHook(ctx, query, pgErr)
+    }
+    return c.failed(ctx, err)
+}
+
+// RunInTx runs fn in a transaction on db and commits it, rolling back if fn
+// returns an error. When the transaction fails with a deadlock (40P01) or a
+// serialization failure (40001), in fn or at commit, it is rolled back and
+// run again from the start after a short randomised backoff, up to 5 attempts
+// in all, so fn must be safe to repeat and should return errors from tx
+// unchanged or wrapped. Other errors, and the error of the last attempt, are
+// returned as is.
+//
+//	err := driver.RunInTx(ctx, db, nil, func(tx *sql.Tx) error {
+//	    _, err := tx.ExecContext(ctx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2", amount, from)
+//	    ...
+//	})
+func RunInTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+    backoff := 10 * time.Millisecond
+    for attempt := 1; ; attempt++ {
+        err := runTx(ctx, db, opts, fn)
+        if err == nil || attempt == txAttempts || !isRetryableTx(err) {
+            return err
+        }
+        timer := time.NewTimer(backoff/2 + time.Duration(rand.Int63n(int64(backoff))))
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return err
+        case <-timer.C:
+        }
+        backoff *= 2
+    }
+}
+
+func runTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+    tx, err := db.BeginTx(ctx, opts)
+    if err != nil {
+        return err
+    }
+    if err := fn(tx); err != nil {
+        tx.Rollback()
+        return err
+    }
+    return tx.Commit()
+}
+
+// isRetryableTx reports whether err aborted a transaction that can succeed
+// when run again.
+func isRetryableTx(err error) bool {
+    var pgErr *pgconn.PgError
+    return errors.As(err, &pgErr) && (pgErr.Code == "40P01" || pgErr.Code == "40001")
+}
@@ -0,0 +1,85 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryableTx(t *testing.T) {
+    for _, tc := range []struct {
+        err  error
+        want bool
+    }{
+        {&pgconn.PgError{Code: "40P01"}, true},
+        {fmt.Errorf("transfer: %w", &pgconn.PgError{Code: "40001"}), true},
+        {&pgconn.PgError{Code: "23505"}, false},
+        {sql.ErrTxDone, false},
+    } {
+        if got := isRetryableTx(tc.err); got != tc.want {
+            t.Errorf("isRetryableTx(%v) = %v", tc.err, got)
+        }
+    }
+}
+
+func TestRunInTxRetriesDeadlock(t *testing.T) {
+    var mu sync.Mutex
+    var hooked []string
+    db, c := testConnectorDB(t, WithDeadlockHook(func(ctx context.Context, query string, err *pgconn.PgError) {
+        mu.Lock()
+        hooked = append(hooked, query)
+        mu.Unlock()
+    }))
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_deadlock", "CREATE TABLE serin_deadlock (id int PRIMARY KEY, n int)", "INSERT INTO serin_deadlock VALUES (1, 0), (2, 0)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_deadlock") })
+    ctx := context.Background()
+
+    // Both transactions lock their first row, wait for each other, then
+    // update the other's row, which deadlocks on the first attempt.
+    var locked sync.WaitGroup
+    locked.Add(2)
+    var attempts atomic.Int32
+    transfer := func(first, second int) error {
+        var once sync.Once
+        return RunInTx(ctx, db, nil, func(tx *sql.Tx) error {
+            attempts.Add(1)
+            if _, err := tx.ExecContext(ctx, "UPDATE serin_deadlock SET n = n + 1 WHERE id = $1", first); err != nil {
+                return err
+            }
+            once.Do(func() {
+                locked.Done()
+                locked.Wait()
+            })
+            _, err := tx.ExecContext(ctx, "UPDATE serin_deadlock SET n = n - 1 WHERE id = $1", second)
+            return err
+        })
+    }
+    errs := make(chan error, 2)
+    go func() { errs <- transfer(1, 2) }()
+    go func() { errs <- transfer(2, 1) }()
+    for i := 0; i < 2; i++ {
+        if err := <-errs; err != nil {
+            t.Errorf("transaction failed despite retries: %v", err)
+        }
+    }
+
+    if n := attempts.Load(); n != 3 {
+        t.Errorf("ran %d attempts, want 3 (one retry)", n)
+    }
+    if m := c.Metrics(); m.DeadlockErrors != 1 {
+        t.Errorf("counted %d deadlocks, want 1", m.DeadlockErrors)
+    }
+    if len(hooked) != 1 || !strings.Contains(hooked[0], "n = n - 1") {
+        t.Errorf("deadlock hook saw %q", hooked)
+    }
+    var sum int
+    if err := db.QueryRow("SELECT sum(n) FROM serin_deadlock").Scan(&sum); err != nil || sum != 0 {
+        t.Errorf("sum %d, %v", sum, err)
+    }
+}
@@ -0,0 +1,100 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strings"
+)
+
+// RunScript runs the semicolon separated statements of script, such as a
+// migration file, one after another on a single connection, stopping at the
+// first failure. The error names the statement and the line it starts on.
+// With atomic the statements run in one transaction that is committed only if
+// all of them succeed; statements that cannot run inside a transaction block,
+// such as CREATE INDEX CONCURRENTLY or VACUUM, then fail. Transaction control
+// statements (BEGIN, COMMIT, ...) are rejected with ErrRawTxControl either way.
+//
+// Semicolons inside string literals (including E'...' strings), quoted
+// identifiers, dollar-quoted strings such as function bodies, comments and
+// BEGIN ATOMIC ... END function bodies do not end a statement. Statements are
+// sent with the simple protocol, so they take no parameters and do not fill
+// the statement cache.
+func (db *DB) RunScript(ctx context.Context, script string, atomic bool) error {
+    stmts := splitScript(script)
+    if len(stmts) == 0 {
+        return nil
+    }
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    type execer interface {
+        ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+    }
+    var ex execer = conn
+    var tx *sql.Tx
+    if atomic {
+        if tx, err = conn.BeginTx(ctx, nil); err != nil {
+            return err
+        }
+        defer tx.Rollback()
+        ex = tx
+    }
+    for i, st := range stmts {
+        if _, err := ex.ExecContext(ctx, st.sql, SimpleProtocol); err != nil {
+            return fmt.Errorf("serin: script statement %d (line %d): %w", i+1, st.line, err)
+        }
+    }
+    if tx != nil {
+        return tx.Commit()
+    }
+    return nil
+}
+
+// scriptStatement is one statement of a script and the line it starts on.
+type scriptStatement struct {
+    sql  string
+    line int
+}
+
+// splitScript splits script on the semicolons that end statements, dropping
+// statements that are empty or only comments.
+func splitScript(script string) []scriptStatement {
+    var out []scriptStatement
+    start, startLine, line := 0, 1, 1
+    emit := func(end int) {
+        text := script[start:end]
+        rest := skipSpaceAndComments(text)
+        if body := strings.TrimRight(rest, " \t\n\r\f\v"); body != "" {
+            skipped := text[:len(text)-len(rest)]
+            out = append(out, scriptStatement{sql: body, line: startLine + strings.Count(skipped, "\n")})
+        }
+    }
+    atomicDepth := 0 // nesting of BEGIN ATOMIC bodies and the CASE expressions in them
+    var prevWord string
+    for i := 0; i < len(script); {
+        kind, n := nextToken(script[i:])
+        switch {
+        case kind == tokenSemicolon && atomicDepth == 0:
+            emit(i)
+            start, startLine = i+1, line
+        case kind == tokenWord:
+            word := strings.ToUpper(script[i : i+n])
+            switch {
+            case word == "ATOMIC" && prevWord == "BEGIN":
+                atomicDepth++
+            case word == "CASE" && atomicDepth > 0:
+                atomicDepth++
+            case word == "END" && atomicDepth > 0:
+                atomicDepth--
+            }
+            prevWord = word
+        }
+        line += strings.Count(script[i:i+n], "\n")
+        i += n
+    }
+    emit(len(script))
+    return out
+}
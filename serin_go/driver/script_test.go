@@ -0,0 +1,108 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestSplitScript(t *testing.T) {
+    script := `-- migration 42
+CREATE TABLE t (id int, note text DEFAULT 'a;b', "odd;col" int);
+
+CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+    NEW.note := 'x;y';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE FUNCTION g(int) RETURNS int AS $body$ SELECT $1; $body$ LANGUAGE sql;
+CREATE FUNCTION h(a int) RETURNS text LANGUAGE sql
+BEGIN ATOMIC
+    SELECT CASE WHEN a > 0 THEN 'pos;' ELSE 'neg' END;
+    SELECT 'done';
+END;
+/* block ; /* nested ; */ still ; */ INSERT INTO t VALUES (1, E'it\'s;', 2);;
+-- trailing comment ;
+`
+    var got []string
+    var lines []int
+    for _, st := range splitScript(script) {
+        got = append(got, st.sql)
+        lines = append(lines, st.line)
+    }
+    want := []string{
+        `CREATE TABLE t (id int, note text DEFAULT 'a;b', "odd;col" int)`,
+        "CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n    NEW.note := 'x;y';\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql",
+        "CREATE FUNCTION g(int) RETURNS int AS $body$ SELECT $1; $body$ LANGUAGE sql",
+        "CREATE FUNCTION h(a int) RETURNS text LANGUAGE sql\nBEGIN ATOMIC\n    SELECT CASE WHEN a > 0 THEN 'pos;' ELSE 'neg' END;\n    SELECT 'done';\nEND",
+        `INSERT INTO t VALUES (1, E'it\'s;', 2)`,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("got  %q\nwant %q", got, want)
+    }
+    if want := []int{2, 4, 10, 11, 16}; !reflect.DeepEqual(lines, want) {
+        t.Errorf("lines %v, want %v", lines, want)
+    }
+}
+
+func TestSplitScriptEmpty(t *testing.T) {
+    for _, s := range []string{"", " ;\n; ", "-- nothing\n/* here */"} {
+        if got := splitScript(s); len(got) != 0 {
+            t.Errorf("splitScript(%q) = %q", s, got)
+        }
+    }
+}
+
+func TestRunScriptAtomicRollsBack(t *testing.T) {
+    db := Wrap(testDB(t))
+    mustExec(t, db.DB, "DROP TABLE IF EXISTS serin_script")
+    t.Cleanup(func() { db.Exec("DROP TABLE IF EXISTS serin_script") })
+    ctx := context.Background()
+
+    err := db.RunScript(ctx, "CREATE TABLE serin_script (id int);\nINSERT INTO serin_script VALUES (1);\nINSERT INTO serin_script VALUES ('x');", true)
+    if err == nil || !strings.Contains(err.Error(), "statement 3 (line 3)") {
+        t.Fatalf("got %v, want an error naming statement 3", err)
+    }
+    var exists bool
+    if err := db.QueryRow("SELECT to_regclass('serin_script') IS NOT NULL").Scan(&exists); err != nil || exists {
+        t.Fatalf("atomic script left its table behind: %v, %v", exists, err)
+    }
+
+    if err := db.RunScript(ctx, "CREATE TABLE serin_script (id int);\nINSERT INTO serin_script VALUES (1);\nINSERT INTO serin_script VALUES ('x');", false); err == nil {
+        t.Fatal("bad insert accepted")
+    }
+    var n int
+    if err := db.QueryRow("SELECT count(*) FROM serin_script").Scan(&n); err != nil || n != 1 {
+        t.Fatalf("non-atomic script kept %d rows, %v", n, err)
+    }
+}
+
+func TestRunScriptFunctionBodies(t *testing.T) {
+    db := Wrap(testDB(t))
+    t.Cleanup(func() { db.Exec("DROP FUNCTION IF EXISTS serin_script_fn(int)") })
+    err := db.RunScript(context.Background(), `
+CREATE OR REPLACE FUNCTION serin_script_fn(n int) RETURNS text AS $$
+BEGIN
+    RETURN 'n=' || n || ';';
+END;
+$$ LANGUAGE plpgsql;
+SELECT serin_script_fn(1);
+`, true)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var s string
+    if err := db.QueryRow("SELECT serin_script_fn(2)").Scan(&s); err != nil || s != "n=2;" {
+        t.Fatalf("got %q, %v", s, err)
+    }
+}
+
+func TestRunScriptRejectsTxControl(t *testing.T) {
+    db := Wrap(testDB(t))
+    if err := db.RunScript(context.Background(), "BEGIN; SELECT 1; COMMIT;", false); !errors.Is(err, ErrRawTxControl) {
+        t.Fatalf("got %v, want ErrRawTxControl", err)
+    }
+}
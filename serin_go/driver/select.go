@@ -0,0 +1,70 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// Select runs query on db through the native pgx path and collects every row
+// into a T. Columns map to struct fields by their `db` tag, falling back to the
+// field name; embedded structs are flattened and pointer fields receive nil
+// for NULL columns.
+func Select[T any](ctx context.Context, db *sql.DB, query string, args ...any) ([]T, error) {
+    var out []T
+    err := withConn(ctx, db, func(c *serinConn) error {
+        rows, err := c.conn.Query(ctx, query, args...)
+        if err != nil {
+            return err
+        }
+        out, err = collectStructs[T](rows)
+        return err
+    })
+    return out, err
+}
+
+// collectStructs drains rows into a slice of T using the `db` tag mapping.
+func collectStructs[T any](rows pgx.Rows) ([]T, error) {
+    return pgx.CollectRows(rows, pgx.RowToStructByName[T])
+}
+
+// ScanValue runs query on db and returns the first column of the first row as a
+// T, or sql.ErrNoRows when there is no row. Scan NULLable columns into pointer
+// types; a NULL into a non-pointer T or a column of an incompatible type is an
+// error naming the column.
+func ScanValue[T any](ctx context.Context, db *sql.DB, query string, args ...any) (T, error) {
+    var v T
+    err := withConn(ctx, db, func(c *serinConn) error {
+        rows, err := c.conn.Query(ctx, query, args...)
+        if err != nil {
+            return err
+        }
+        v, err = scanFirstValue[T](rows)
+        return err
+    })
+    return v, err
+}
+
+func scanFirstValue[T any](rows pgx.Rows) (T, error) {
+    defer rows.Close()
+    var v T
+    if !rows.Next() {
+        if err := rows.Err(); err != nil {
+            return v, err
+        }
+        return v, sql.ErrNoRows
+    }
+    fields := rows.FieldDescriptions()
+    if len(fields) == 0 {
+        return v, fmt.Errorf("serin: ScanValue query returned no columns")
+    }
+    dest := make([]any, len(fields)) // nil destinations skip the other columns
+    dest[0] = &v
+    if err := rows.Scan(dest...); err != nil {
+        return v, fmt.Errorf("serin: scanning column %q into %T: %w", fields[0].Name, v, err)
+    }
+    rows.Close()
+    return v, rows.Err()
+}
@@ -0,0 +1,85 @@
+package driver
+
+import (
+    "database/sql"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+type audit struct {
+    CreatedBy string `db:"created_by"`
+}
+
+type account struct {
+    audit
+    ID       int64   `db:"id"`
+    Name     string  `db:"display_name"`
+    Nickname *string `db:"nickname"`
+}
+
+func TestCollectStructsTagMapping(t *testing.T) {
+    rows := newFakeRows(
+        []fakeCol{{"id", pgtype.Int8OID}, {"display_name", pgtype.TextOID}, {"nickname", pgtype.TextOID}, {"created_by", pgtype.TextOID}},
+        []any{"1", "Alice", "ally", "admin"},
+        []any{"2", "Bob", nil, "system"},
+    )
+    got, err := collectStructs[account](rows)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(got) != 2 {
+        t.Fatalf("got %d rows, want 2", len(got))
+    }
+    if got[0].ID != 1 || got[0].Name != "Alice" || got[0].CreatedBy != "admin" {
+        t.Errorf("row 0 mapped incorrectly: %+v", got[0])
+    }
+    if got[0].Nickname == nil || *got[0].Nickname != "ally" {
+        t.Errorf("row 0 nickname = %v, want ally", got[0].Nickname)
+    }
+    if got[1].Nickname != nil {
+        t.Errorf("row 1 nickname = %q, want nil for NULL", *got[1].Nickname)
+    }
+}
+
+func TestCollectStructsMissingField(t *testing.T) {
+    rows := newFakeRows([]fakeCol{{"id", pgtype.Int8OID}, {"unknown", pgtype.TextOID}}, []any{"1", "x"})
+    if _, err := collectStructs[account](rows); err == nil {
+        t.Fatal("expected error for column without a matching field")
+    }
+}
+
+func TestScanFirstValue(t *testing.T) {
+    n, err := scanFirstValue[int](newFakeRows([]fakeCol{{"n", pgtype.Int8OID}, {"extra", pgtype.TextOID}}, []any{"42", "x"}, []any{"7", "y"}))
+    if err != nil || n != 42 {
+        t.Errorf("int: got %d, %v", n, err)
+    }
+    s, err := scanFirstValue[string](newFakeRows([]fakeCol{{"s", pgtype.TextOID}}, []any{"hello"}))
+    if err != nil || s != "hello" {
+        t.Errorf("string: got %q, %v", s, err)
+    }
+    ts, err := scanFirstValue[time.Time](newFakeRows([]fakeCol{{"at", pgtype.TimestamptzOID}}, []any{"2024-03-10 07:30:15.5+00"}))
+    if want := time.Date(2024, 3, 10, 7, 30, 15, 500000000, time.UTC); err != nil || !ts.Equal(want) {
+        t.Errorf("time: got %v, %v", ts, err)
+    }
+    p, err := scanFirstValue[*int64](newFakeRows([]fakeCol{{"n", pgtype.Int8OID}}, []any{nil}))
+    if err != nil || p != nil {
+        t.Errorf("NULL into pointer: got %v, %v", p, err)
+    }
+}
+
+func TestScanFirstValueErrors(t *testing.T) {
+    if _, err := scanFirstValue[int](newFakeRows([]fakeCol{{"n", pgtype.Int8OID}})); !errors.Is(err, sql.ErrNoRows) {
+        t.Errorf("no rows: got %v, want sql.ErrNoRows", err)
+    }
+    if _, err := scanFirstValue[int](newFakeRows([]fakeCol{{"n", pgtype.Int8OID}}, []any{nil})); err == nil {
+        t.Error("NULL into int succeeded")
+    }
+    _, err := scanFirstValue[time.Time](newFakeRows([]fakeCol{{"label", pgtype.TextOID}}, []any{"abc"}))
+    if err == nil || !strings.Contains(err.Error(), `column "label"`) {
+        t.Errorf("type mismatch: got %v", err)
+    }
+}
@@ -0,0 +1,62 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+)
+
+// SetSession sets the run-time parameter name to value for the rest of the
+// session on conn, like SET. The driver resets it when conn is returned to
+// the pool, so the next borrower sees the defaults again. Plain SET
+// statements run through the driver are tracked the same way.
+func SetSession(ctx context.Context, conn *sql.Conn, name, value string) error {
+    return conn.Raw(func(dc any) error {
+        c := dc.(*serinConn)
+        c.sessionDirty = true
+        _, err := c.conn.Exec(ctx, "SELECT set_config($1, $2, false)", name, value)
+        if err != nil {
+            return c.failed(ctx, err)
+        }
+        return nil
+    })
+}
+
+// isSessionSet reports whether query changes a session-level setting. SET
+// LOCAL and SET TRANSACTION only last until the end of the transaction and
+// need no cleanup.
+func isSessionSet(query string) bool {
+    kw := leadingKeywords(query, 2)
+    if len(kw) == 0 || kw[0] != "SET" {
+        return false
+    }
+    return len(kw) < 2 || kw[1] != "LOCAL" && kw[1] != "TRANSACTION" && kw[1] != "CONSTRAINTS"
+}
+
+// resetSession undoes session state left by the previous borrower of c: with
+// discard_all everything, otherwise the settings changed through SET or
+// SetSession.
+func (c *serinConn) resetSession(ctx context.Context) error {
+    if c.connector.discardAll {
+        if _, err := c.conn.Exec(ctx, "DISCARD ALL"); err != nil {
+            return err
+        }
+        // DISCARD ALL dropped the prepared statements pgx and the cache
+        // know about.
+        if err := c.conn.DeallocateAll(ctx); err != nil {
+            return err
+        }
+        if c.stmts != nil {
+            c.stmts.reset()
+        }
+        c.sessionDirty = false
+        return nil
+    }
+    if !c.sessionDirty {
+        return nil
+    }
+    if _, err := c.conn.Exec(ctx, "RESET ALL; RESET ROLE"); err != nil {
+        return err
+    }
+    c.sessionDirty = false
+    return nil
+}
@@ -0,0 +1,116 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "testing"
+)
+
+func TestIsSessionSet(t *testing.T) {
+    for q, want := range map[string]bool{
+        "SET search_path TO app":                       true,
+        "set SESSION statement_timeout = 5":            true,
+        "/* hint */ SET application_name = 'x'":        true,
+        "SET LOCAL statement_timeout = 5":              false,
+        "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE": false,
+        "SET CONSTRAINTS ALL DEFERRED":                 false,
+        "SELECT 'SET x = 1'":                           false,
+    } {
+        if got := isSessionSet(q); got != want {
+            t.Errorf("isSessionSet(%q) = %v", q, got)
+        }
+    }
+}
+
+// borrow runs fn on a connection taken from db and returns it to the pool.
+func borrow(t *testing.T, db *sql.DB, fn func(conn *sql.Conn)) {
+    t.Helper()
+    conn, err := db.Conn(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    fn(conn)
+}
+
+func show(t *testing.T, conn *sql.Conn, name string) string {
+    t.Helper()
+    var v string
+    if err := conn.QueryRowContext(context.Background(), "SELECT current_setting($1)", name).Scan(&v); err != nil {
+        t.Fatal(err)
+    }
+    return v
+}
+
+func TestSessionSettingsResetBetweenBorrowers(t *testing.T) {
+    db, _ := testConnectorDB(t)
+    db.SetMaxOpenConns(1)
+    ctx := context.Background()
+    var defaultName, defaultTZ string
+    borrow(t, db, func(conn *sql.Conn) {
+        defaultName, defaultTZ = show(t, conn, "application_name"), show(t, conn, "TimeZone")
+    })
+
+    borrow(t, db, func(conn *sql.Conn) {
+        if _, err := conn.ExecContext(ctx, "SET application_name = 'borrower one'"); err != nil {
+            t.Fatal(err)
+        }
+        if err := SetSession(ctx, conn, "TimeZone", "Asia/Tokyo"); err != nil {
+            t.Fatal(err)
+        }
+        if show(t, conn, "application_name") != "borrower one" || show(t, conn, "TimeZone") != "Asia/Tokyo" {
+            t.Fatal("settings not applied")
+        }
+    })
+
+    borrow(t, db, func(conn *sql.Conn) {
+        if got := show(t, conn, "application_name"); got != defaultName {
+            t.Errorf("application_name leaked: %q", got)
+        }
+        if got := show(t, conn, "TimeZone"); got != defaultTZ {
+            t.Errorf("TimeZone leaked: %q", got)
+        }
+    })
+}
+
+func TestDiscardAllBetweenBorrowers(t *testing.T) {
+    db, _ := testConnectorDB(t, WithDiscardAll())
+    db.SetMaxOpenConns(1)
+    ctx := context.Background()
+    borrow(t, db, func(conn *sql.Conn) {
+        mustExecConn(t, conn, "CREATE TEMP TABLE serin_scratch (n int)", "SET work_mem = '1MB'")
+        // Leave a cached prepared statement behind.
+        if err := conn.QueryRowContext(ctx, "SELECT $1::int", 1).Err(); err != nil {
+            t.Fatal(err)
+        }
+    })
+    borrow(t, db, func(conn *sql.Conn) {
+        var gone bool
+        if err := conn.QueryRowContext(ctx, "SELECT to_regclass('pg_temp.serin_scratch') IS NULL").Scan(&gone); err != nil || !gone {
+            t.Errorf("temporary table survived: %v", err)
+        }
+        var n int
+        if err := conn.QueryRowContext(ctx, "SELECT $1::int", 2).Scan(&n); err != nil || n != 2 {
+            t.Errorf("cached statement after DISCARD ALL: %d, %v", n, err)
+        }
+    })
+}
+
+func TestDiscardAllParam(t *testing.T) {
+    c, err := NewConnector("host=127.0.0.1 discard_all=true")
+    if err != nil || !c.discardAll {
+        t.Fatalf("got %v, %v", c.discardAll, err)
+    }
+    if _, err := NewConnector("host=127.0.0.1 discard_all=maybe"); err == nil {
+        t.Error("invalid discard_all accepted")
+    }
+}
+
+func mustExecConn(t *testing.T, conn *sql.Conn, stmts ...string) {
+    t.Helper()
+    for _, s := range stmts {
+        if _, err := conn.ExecContext(context.Background(), s); err != nil {
+            t.Fatalf("%s: %v", s, err)
+        }
+    }
+}
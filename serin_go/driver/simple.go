@@ -0,0 +1,77 @@
+package driver
+
+import (
+    "context"
+    "errors"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// SimpleProtocol, passed as the first query argument, sends the statement
+// verbatim over the simple query protocol instead of preparing it. Remaining
+// arguments are interpolated client-side by pgx.
+//
+//	db.ExecContext(ctx, "VECTOR SEARCH items NEAR $1", driver.SimpleProtocol, vec)
+const SimpleProtocol = pgx.QueryExecModeSimpleProtocol
+
+// simpleMode strips a leading SimpleProtocol marker from args.
+func simpleMode(args []any) ([]any, bool) {
+    if len(args) > 0 {
+        if mode, ok := args[0].(pgx.QueryExecMode); ok && mode == SimpleProtocol {
+            return args[1:], true
+        }
+    }
+    return args, false
+}
+
+// forceSimple prefixes args with the SimpleProtocol marker understood by pgx.
+func forceSimple(args []any) []any {
+    return append([]any{SimpleProtocol}, args...)
+}
+
+// simpleFallback reports whether err is the server refusing to describe a
+// statement that the simple protocol may still run, such as proprietary
+// syntax or several statements in one string. A refusal inside a transaction
+// has already aborted it, so only idle connections fall back. Callers check
+// that err came from describing the statement: the same SQLSTATEs raised
+// while it executed mean it may already have had effects, and running it
+// again would repeat them.
+func (c *serinConn) simpleFallback(err error) bool {
+    var pgErr *pgconn.PgError
+    if !errors.As(err, &pgErr) || c.conn.PgConn().TxStatus() != 'I' {
+        return false
+    }
+    switch pgErr.Code {
+    case "0A000": // feature_not_supported
+        return true
+    case "42601": // syntax_error
+        return strings.Contains(pgErr.Message, "multiple commands")
+    }
+    return false
+}
+
+// describeTracer records whether pgx's own describe step of the current Exec
+// or Query, which it takes in the describe_exec and cache_describe modes used
+// without the statement cache, failed. Only then was nothing executed, so the
+// statement may be retried over the simple protocol; in the exec mode pgx
+// sends the statement in one go and it is never retried.
+type describeTracer struct {
+    refused bool
+}
+
+func (t *describeTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+    t.refused = false
+    return ctx
+}
+
+func (t *describeTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func (t *describeTracer) TracePrepareStart(ctx context.Context, _ *pgx.Conn, _ pgx.TracePrepareStartData) context.Context {
+    return ctx
+}
+
+func (t *describeTracer) TracePrepareEnd(_ context.Context, _ *pgx.Conn, data pgx.TracePrepareEndData) {
+    t.refused = data.Err != nil
+}
@@ -0,0 +1,155 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "net"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgproto3"
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestSimpleMode(t *testing.T) {
+    args, ok := simpleMode([]any{SimpleProtocol, 1, "x"})
+    if !ok || len(args) != 2 || args[0] != 1 {
+        t.Errorf("got %v, %v", args, ok)
+    }
+    if _, ok := simpleMode([]any{1}); ok {
+        t.Error("plain arguments reported as simple protocol")
+    }
+}
+
+func TestSimpleProtocolFallback(t *testing.T) {
+    db := testDB(t)
+    ctx := context.Background()
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_simple")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_simple") })
+
+    // Several statements in one string cannot be described by the extended
+    // protocol; the driver should retry them verbatim.
+    if _, err := db.ExecContext(ctx, "CREATE TABLE serin_simple (n int); INSERT INTO serin_simple VALUES ($1)", 7); err != nil {
+        t.Fatal(err)
+    }
+    var n int
+    if err := db.QueryRowContext(ctx, "SELECT n FROM serin_simple WHERE n = $1", 7).Scan(&n); err != nil || n != 7 {
+        t.Fatalf("got %d, %v", n, err)
+    }
+
+    // Inside a transaction the failed describe aborts it, so callers must opt
+    // in up front.
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer tx.Rollback()
+    if _, err := tx.ExecContext(ctx, "INSERT INTO serin_simple VALUES ($1); INSERT INTO serin_simple VALUES ($1)", SimpleProtocol, 8); err != nil {
+        t.Fatal(err)
+    }
+    if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM serin_simple WHERE n = $1", 8).Scan(&n); err != nil || n != 2 {
+        t.Fatalf("got %d, %v", n, err)
+    }
+}
+
+// unsupportedServer fakes a server that refuses to parse statements
+// containing VECTOR and raises feature_not_supported while executing every
+// other extended protocol statement, all of which take one int4 parameter.
+// executed counts the statements run.
+func unsupportedServer(t *testing.T, executed *atomic.Int32) (host, port string) {
+    return fakeServer(t, func(c net.Conn) {
+        be := pgproto3.NewBackend(c, c)
+        if _, err := be.ReceiveStartupMessage(); err != nil {
+            return
+        }
+        be.Send(&pgproto3.AuthenticationOk{})
+        be.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+        be.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+        be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+        be.Flush()
+        unsupported := &pgproto3.ErrorResponse{Severity: "ERROR", Code: "0A000", Message: "not supported"}
+        failed := false // skipping messages up to the next Sync
+        for {
+            msg, err := be.Receive()
+            if err != nil {
+                return
+            }
+            switch msg := msg.(type) {
+            case *pgproto3.Query:
+                if !strings.Contains(msg.String, "pg_type") { // not the driver's type lookups
+                    executed.Add(1)
+                }
+                be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 0")})
+                be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+            case *pgproto3.Sync:
+                failed = false
+                be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+            case *pgproto3.Parse:
+                if failed {
+                    continue
+                }
+                if strings.Contains(msg.Query, "VECTOR") {
+                    be.Send(unsupported)
+                    failed = true
+                } else {
+                    be.Send(&pgproto3.ParseComplete{})
+                }
+            case *pgproto3.Describe:
+                if failed {
+                    continue
+                }
+                if msg.ObjectType == 'S' {
+                    be.Send(&pgproto3.ParameterDescription{ParameterOIDs: []uint32{pgtype.Int4OID}})
+                }
+                be.Send(&pgproto3.NoData{})
+            case *pgproto3.Bind:
+                if !failed {
+                    be.Send(&pgproto3.BindComplete{})
+                }
+            case *pgproto3.Execute:
+                if !failed {
+                    executed.Add(1)
+                    be.Send(unsupported)
+                    failed = true
+                }
+            case *pgproto3.Terminate:
+                return
+            }
+            be.Flush()
+        }
+    })
+}
+
+func TestSimpleFallbackOnlyAfterDescribe(t *testing.T) {
+    var executed atomic.Int32
+    host, port := unsupportedServer(t, &executed)
+    c, err := NewConnector("host="+host+" port="+port+" user=alice sslmode=disable", WithStatementCacheCapacity(0))
+    if err != nil {
+        t.Fatal(err)
+    }
+    db := sql.OpenDB(c)
+    defer db.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    // feature_not_supported raised while executing must not run it again.
+    var pgErr *pgconn.PgError
+    if _, err := db.ExecContext(ctx, "SELECT unsupported($1)", 1); !errors.As(err, &pgErr) || pgErr.Code != "0A000" {
+        t.Fatalf("got %v", err)
+    }
+    if n := executed.Load(); n != 1 {
+        t.Errorf("statement failing in Execute ran %d times, want 1", n)
+    }
+
+    // A statement the server cannot describe is retried verbatim.
+    if _, err := db.ExecContext(ctx, "VECTOR SEARCH items NEAR $1", 1); err != nil {
+        t.Fatal(err)
+    }
+    if n := executed.Load(); n != 2 {
+        t.Errorf("ran %d statements, want 2", n)
+    }
+}
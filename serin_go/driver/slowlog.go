@@ -0,0 +1,121 @@
+package driver
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// slowQueryLog holds the WithSlowQueryLog settings of a Connector.
+type slowQueryLog struct {
+    threshold time.Duration // zero disables the log
+    plan      bool
+    args      bool // log argument values instead of their types
+}
+
+// WithSlowQueryLog logs every query that takes longer than threshold, timed
+// until its rows are closed, as a warning on the connector's logger. Arguments
+// are redacted to their Go types unless WithSlowQueryArgs is also given. With
+// withPlan the driver runs EXPLAIN for the query afterwards, in a read-only
+// transaction or, inside the caller's transaction, a savepoint that is rolled
+// back, and attaches the plan to the event.
+func WithSlowQueryLog(threshold time.Duration, withPlan bool) Option {
+    return func(c *Connector) {
+        c.slowQuery.threshold = threshold
+        c.slowQuery.plan = withPlan
+    }
+}
+
+// WithSlowQueryArgs includes argument values in slow query events.
+func WithSlowQueryArgs() Option {
+    return func(c *Connector) { c.slowQuery.args = true }
+}
+
+// WithLogger sets the logger for driver events such as slow queries. The
+// default is slog.Default().
+func WithLogger(l *slog.Logger) Option {
+    return func(c *Connector) { c.logger = l }
+}
+
+func (c *Connector) log() *slog.Logger {
+    if c.logger != nil {
+        return c.logger
+    }
+    return slog.Default()
+}
+
+// observe logs query if it started more than the slow query threshold ago.
+func (c *serinConn) observe(ctx context.Context, start time.Time, query string, args []any) {
+    sl := c.connector.slowQuery
+    elapsed := time.Since(start)
+    if sl.threshold <= 0 || elapsed < sl.threshold {
+        return
+    }
+    args, _ = simpleMode(args)
+    attrs := []any{slog.Duration("duration", elapsed), slog.String("query", query), slog.Any("args", logArgs(args, sl.args))}
+    attrs = append(attrs, metaAttr(ctx)...)
+    if sl.plan && ctx.Err() == nil && !c.conn.IsClosed() {
+        plan, err := c.explain(ctx, query, args)
+        if err != nil {
+            attrs = append(attrs, slog.String("plan_error", err.Error()))
+        } else if plan != "" {
+            attrs = append(attrs, slog.String("plan", plan))
+        }
+    }
+    c.connector.log().WarnContext(ctx, "serin: slow query", attrs...)
+}
+
+func logArgs(args []any, values bool) []any {
+    out := make([]any, len(args))
+    for i, a := range args {
+        if values {
+            out[i] = a
+        } else {
+            out[i] = fmt.Sprintf("%T", a)
+        }
+    }
+    return out
+}
+
+// explain returns the EXPLAIN output for query without executing it. Only
+// plannable statements are explained; others yield an empty plan.
+func (c *serinConn) explain(ctx context.Context, query string, args []any) (string, error) {
+    if kw := leadingKeywords(query, 1); len(kw) == 0 || !explainable[kw[0]] {
+        return "", nil
+    }
+    query, args, err := expandIn(query, args)
+    if err != nil {
+        return "", err
+    }
+    var begin, end string
+    switch c.conn.PgConn().TxStatus() {
+    case 'I':
+        begin, end = "BEGIN READ ONLY", "ROLLBACK"
+    case 'T':
+        begin, end = "SAVEPOINT serin_explain", "ROLLBACK TO SAVEPOINT serin_explain; RELEASE SAVEPOINT serin_explain"
+    default:
+        return "", nil
+    }
+    if _, err := c.conn.Exec(ctx, begin); err != nil {
+        return "", err
+    }
+    defer c.conn.Exec(ctx, end)
+    rows, err := c.conn.Query(ctx, "EXPLAIN "+query, args...)
+    if err != nil {
+        return "", err
+    }
+    lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+    if err != nil {
+        return "", err
+    }
+    return strings.Join(lines, "\n"), nil
+}
+
+var explainable = map[string]bool{
+    "SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true,
+    "MERGE": true, "VALUES": true, "WITH": true, "TABLE": true,
+}
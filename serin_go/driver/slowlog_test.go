@@ -0,0 +1,63 @@
+package driver
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "log/slog"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestLogArgsRedacts(t *testing.T) {
+    if got := logArgs([]any{int64(1), "secret"}, false); !reflect.DeepEqual(got, []any{"int64", "string"}) {
+        t.Errorf("got %v", got)
+    }
+    if got := logArgs([]any{"secret"}, true); !reflect.DeepEqual(got, []any{"secret"}) {
+        t.Errorf("got %v", got)
+    }
+}
+
+func TestSlowQueryLog(t *testing.T) {
+    var buf bytes.Buffer
+    logger := slog.New(slog.NewJSONHandler(&buf, nil))
+    db, _ := testConnectorDB(t, WithSlowQueryLog(50*time.Millisecond, true), WithLogger(logger))
+    ctx := context.Background()
+
+    if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
+        t.Fatal(err)
+    }
+    if buf.Len() != 0 {
+        t.Fatalf("fast query logged: %s", buf.String())
+    }
+
+    rows, err := db.QueryContext(ctx, "SELECT pg_sleep(0.1), $1::text", "secret")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for rows.Next() {
+    }
+    rows.Close()
+
+    var event struct {
+        Msg      string
+        Query    string
+        Args     []string
+        Duration int64
+        Plan     string
+    }
+    if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+        t.Fatalf("%v: %s", err, buf.String())
+    }
+    if event.Msg != "serin: slow query" || !strings.Contains(event.Query, "pg_sleep") || event.Duration < int64(50*time.Millisecond) {
+        t.Errorf("unexpected event %+v", event)
+    }
+    if !reflect.DeepEqual(event.Args, []string{"string"}) {
+        t.Errorf("arguments not redacted: %v", event.Args)
+    }
+    if !strings.Contains(event.Plan, "Result") {
+        t.Errorf("missing plan: %q", event.Plan)
+    }
+}
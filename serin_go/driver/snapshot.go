@@ -0,0 +1,31 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+)
+
+type snapshotKey struct{}
+
+// ExportSnapshot exports the snapshot of tx with pg_export_snapshot so other
+// connections can read exactly the same data via WithSnapshot. The id stays
+// importable only while tx is open. Begin tx at REPEATABLE READ or
+// SERIALIZABLE so the snapshot also covers its own later queries.
+func ExportSnapshot(ctx context.Context, tx *sql.Tx) (string, error) {
+    var id string
+    err := tx.QueryRowContext(ctx, "SELECT pg_export_snapshot()").Scan(&id)
+    return id, err
+}
+
+// WithSnapshot returns a context that makes db.BeginTx adopt the exported
+// snapshot id with SET TRANSACTION SNAPSHOT, for consistent parallel reads
+// across worker connections. Snapshots can only be imported at REPEATABLE READ
+// or SERIALIZABLE; the default isolation level becomes REPEATABLE READ.
+func WithSnapshot(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, snapshotKey{}, id)
+}
+
+func snapshotFrom(ctx context.Context) string {
+    id, _ := ctx.Value(snapshotKey{}).(string)
+    return id
+}
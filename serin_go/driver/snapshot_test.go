@@ -0,0 +1,49 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "testing"
+)
+
+func TestExportAndImportSnapshot(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_snapshot", "CREATE TABLE serin_snapshot (id int)", "INSERT INTO serin_snapshot VALUES (1), (2)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_snapshot") })
+    ctx := context.Background()
+
+    exporter, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer exporter.Rollback()
+    id, err := ExportSnapshot(ctx, exporter)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if id == "" {
+        t.Fatal("empty snapshot id")
+    }
+
+    // Committed after the export, so invisible to the snapshot.
+    mustExec(t, db, "INSERT INTO serin_snapshot VALUES (3)")
+
+    worker, err := db.BeginTx(WithSnapshot(ctx, id), &sql.TxOptions{ReadOnly: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer worker.Rollback()
+    for name, tx := range map[string]*sql.Tx{"exporter": exporter, "worker": worker} {
+        var n int
+        if err := tx.QueryRow("SELECT count(*) FROM serin_snapshot").Scan(&n); err != nil {
+            t.Fatal(err)
+        }
+        if n != 2 {
+            t.Errorf("%s sees %d rows, want the 2 in the snapshot", name, n)
+        }
+    }
+
+    if _, err := db.BeginTx(WithSnapshot(ctx, "00000003-DEADBEEF-1"), nil); err == nil {
+        t.Error("importing an unknown snapshot succeeded")
+    }
+}
@@ -0,0 +1,41 @@
+package driver
+
+import (
+    "fmt"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// socketPrefix starts the file name of a server's Unix socket, which ends in
+// the port number: /var/run/serin/.s.PGSQL.5432 for port 5432.
+const socketPrefix = ".s.PGSQL."
+
+// WithUnixSocket connects over the Unix domain socket at path instead of the
+// hosts of the DSN, skipping TCP and TLS for lower latency on the same host.
+// path is either the socket directory, like host=/var/run/serin in the DSN,
+// with the socket file named after the DSN's port, or the socket file itself,
+// such as /var/run/serin/.s.PGSQL.5433, whose name then sets the port. It
+// must be absolute.
+func WithUnixSocket(path string) Option {
+    return func(c *Connector) { c.socket = path }
+}
+
+// useUnixSocket points the connection config at the socket path.
+func (c *Connector) useUnixSocket(path string) error {
+    if !filepath.IsAbs(path) {
+        return fmt.Errorf("serin: Unix socket path %q is not absolute", path)
+    }
+    dir, port := path, c.config.Port
+    if base := filepath.Base(path); strings.HasPrefix(base, socketPrefix) {
+        p, err := strconv.ParseUint(base[len(socketPrefix):], 10, 16)
+        if err != nil {
+            return fmt.Errorf("serin: Unix socket %q does not end in a port number", path)
+        }
+        dir, port = filepath.Dir(path), uint16(p)
+    }
+    c.config.Host, c.config.Port = dir, port
+    c.config.TLSConfig = nil
+    c.config.Fallbacks = nil
+    return nil
+}
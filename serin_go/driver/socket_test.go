@@ -0,0 +1,29 @@
+package driver
+
+import "testing"
+
+func TestUnixSocketPaths(t *testing.T) {
+    for _, tc := range []struct {
+        dsn  string
+        opts []Option
+        host string
+        port uint16
+    }{
+        {"host=/var/run/serin port=5433", nil, "/var/run/serin", 5433},
+        {"host=db.internal port=5433 sslmode=require", []Option{WithUnixSocket("/var/run/serin")}, "/var/run/serin", 5433},
+        {"host=db.internal", []Option{WithUnixSocket("/tmp/serin/.s.PGSQL.6000")}, "/tmp/serin", 6000},
+    } {
+        c, err := NewConnector(tc.dsn, tc.opts...)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if c.config.Host != tc.host || c.config.Port != tc.port || c.config.TLSConfig != nil || len(c.config.Fallbacks) != 0 {
+            t.Errorf("%s: host %s port %d tls %v fallbacks %d", tc.dsn, c.config.Host, c.config.Port, c.config.TLSConfig != nil, len(c.config.Fallbacks))
+        }
+    }
+    for _, path := range []string{"run/serin", "/tmp/serin/.s.PGSQL.x"} {
+        if _, err := NewConnector("host=db.internal", WithUnixSocket(path)); err == nil {
+            t.Errorf("socket path %q accepted", path)
+        }
+    }
+}
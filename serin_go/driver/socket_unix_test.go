@@ -0,0 +1,48 @@
+//go:build unix
+
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "net"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestUnixSocketConnect(t *testing.T) {
+    // Socket paths are limited to about 100 bytes, so keep the directory short.
+    dir, err := os.MkdirTemp("", "serin")
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { os.RemoveAll(dir) })
+    l, err := net.Listen("unix", filepath.Join(dir, ".s.PGSQL.5432"))
+    if err != nil {
+        t.Skipf("Unix sockets unavailable: %v", err)
+    }
+    serveFake(t, l, serveNotices)
+
+    for _, c := range []func() (*Connector, error){
+        func() (*Connector, error) { return NewConnector("host=" + dir + " port=5432 user=alice") },
+        func() (*Connector, error) {
+            return NewConnector("host=db.invalid user=alice", WithUnixSocket(l.Addr().String()))
+        },
+    } {
+        connector, err := c()
+        if err != nil {
+            t.Fatal(err)
+        }
+        db := sql.OpenDB(connector)
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        var n int
+        err = db.QueryRowContext(ctx, "SELECT progress()", SimpleProtocol).Scan(&n)
+        cancel()
+        db.Close()
+        if err != nil || n != 7 {
+            t.Fatalf("query over %s: %d, %v", connector.config.Host, n, err)
+        }
+    }
+}
@@ -0,0 +1,70 @@
+package driver
+
+import (
+    "context"
+    "net/url"
+    "slices"
+    "strings"
+)
+
+// WithSQLCommenter appends a sqlcommenter style comment with the tags fn
+// returns for the statement's context to every statement, so server logs,
+// pg_stat_activity and APM tools can attribute queries:
+//
+//	SELECT ... /*app='billing',traceparent='00-4bf9...-01'*/
+//
+// Keys are sorted and keys and values URL-encoded, so a tag cannot close the
+// comment or alter the statement; an empty map adds nothing. To tag queries
+// with their WithQueryMeta metadata, pass driver.QueryMeta.
+//
+// The comment is part of the statement text, so tags that change with every
+// request, such as trace ids, would make each statement distinct. Statements
+// that get tags therefore bypass the statement cache and are prepared unnamed
+// each time they run, as without the cache; statements for which fn returns
+// no tags are cached as usual.
+func WithSQLCommenter(fn func(ctx context.Context) map[string]string) Option {
+    return func(c *Connector) { c.commenter = fn }
+}
+
+// sqlComment returns query with the comment for tags appended.
+func sqlComment(query string, tags map[string]string) string {
+    if len(tags) == 0 {
+        return query
+    }
+    keys := make([]string, 0, len(tags))
+    for k := range tags {
+        keys = append(keys, k)
+    }
+    slices.Sort(keys)
+    var b strings.Builder
+    b.WriteString(query)
+    // A space would leave the comment inside a trailing -- comment.
+    if endsInLineComment(query) {
+        b.WriteByte('\n')
+    } else {
+        b.WriteByte(' ')
+    }
+    b.WriteString("/*")
+    for i, k := range keys {
+        if i > 0 {
+            b.WriteByte(',')
+        }
+        b.WriteString(url.QueryEscape(k))
+        b.WriteString("='")
+        b.WriteString(url.QueryEscape(tags[k]))
+        b.WriteByte('\'')
+    }
+    b.WriteString("*/")
+    return b.String()
+}
+
+// endsInLineComment reports whether query ends inside a -- comment.
+func endsInLineComment(query string) bool {
+    var last tokenKind
+    for i := 0; i < len(query); {
+        kind, n := nextToken(query[i:])
+        last = kind
+        i += n
+    }
+    return last == tokenLineComment
+}
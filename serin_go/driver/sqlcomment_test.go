@@ -0,0 +1,63 @@
+package driver
+
+import (
+    "context"
+    "strconv"
+    "strings"
+    "testing"
+)
+
+func TestSQLComment(t *testing.T) {
+    for _, tc := range []struct {
+        query string
+        tags  map[string]string
+        want  string
+    }{
+        {"SELECT 1", nil, "SELECT 1"},
+        {"SELECT 1", map[string]string{"route": "/users/{id}", "app": "svc"}, "SELECT 1 /*app='svc',route='%2Fusers%2F%7Bid%7D'*/"},
+        {"SELECT 1", map[string]string{"x*/; DROP TABLE t; /*": "it's */ --"}, "SELECT 1 /*x%2A%2F%3B+DROP+TABLE+t%3B+%2F%2A='it%27s+%2A%2F+--'*/"},
+        {"SELECT 1 -- note", map[string]string{"app": "svc"}, "SELECT 1 -- note\n/*app='svc'*/"},
+    } {
+        if got := sqlComment(tc.query, tc.tags); got != tc.want {
+            t.Errorf("got  %s\nwant %s", got, tc.want)
+        }
+    }
+}
+
+func TestSQLCommenter(t *testing.T) {
+    db, _ := testConnectorDB(t, WithSQLCommenter(QueryMeta))
+    ctx := WithQueryMeta(context.Background(), map[string]string{"app": "svc", "traceparent": "00-abc-01"})
+    var q string
+    var n int
+    if err := db.QueryRowContext(ctx, "SELECT current_query(), $1::int + 1 -- trailing", 41).Scan(&q, &n); err != nil {
+        t.Fatal(err)
+    }
+    if n != 42 {
+        t.Errorf("got %d, want 42", n)
+    }
+    if !strings.HasSuffix(q, "\n/*app='svc',traceparent='00-abc-01'*/") {
+        t.Errorf("comment missing from %q", q)
+    }
+    if err := db.QueryRowContext(context.Background(), "SELECT current_query()").Scan(&q); err != nil || q != "SELECT current_query()" {
+        t.Errorf("untagged query is %q, %v", q, err)
+    }
+}
+
+// Statements tagged with per-request values must not churn the statement cache.
+func TestSQLCommenterBypassesCache(t *testing.T) {
+    var n int
+    db, c := testConnectorDB(t, WithSQLCommenter(func(context.Context) map[string]string {
+        n++
+        return map[string]string{"traceparent": "00-" + strconv.Itoa(n) + "-01"}
+    }))
+    db.SetMaxOpenConns(1)
+    for i := 0; i < 3; i++ {
+        var v int
+        if err := db.QueryRow("SELECT $1::int", i).Scan(&v); err != nil || v != i {
+            t.Fatalf("got %d, %v", v, err)
+        }
+    }
+    if m := c.Metrics(); m.StatementCacheMisses != 0 || m.StatementCacheEvictions != 0 {
+        t.Errorf("tagged statements reached the cache: %+v", m)
+    }
+}
@@ -0,0 +1,161 @@
+package driver
+
+import (
+    "container/list"
+    "context"
+    "errors"
+    "log/slog"
+    "strconv"
+    "strings"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// stmtCache is a per-connection LRU of server side prepared statements keyed by
+// SQL text. It is not safe for concurrent use; database/sql serialises access
+// to a connection.
+type stmtCache struct {
+    capacity int
+    seq      int
+    lru      *list.List // of *cachedStmt, most recently used first
+    bySQL    map[string]*list.Element
+    evicted  []string // statement names still to be deallocated
+}
+
+type cachedStmt struct {
+    sql  string
+    name string
+}
+
+func newStmtCache(capacity int) *stmtCache {
+    return &stmtCache{capacity: capacity, lru: list.New(), bySQL: make(map[string]*list.Element)}
+}
+
+// get returns the statement name prepared for sql and marks it recently used.
+func (sc *stmtCache) get(sql string) (string, bool) {
+    el, ok := sc.bySQL[sql]
+    if !ok {
+        return "", false
+    }
+    sc.lru.MoveToFront(el)
+    return el.Value.(*cachedStmt).name, true
+}
+
+// nextName returns a statement name not used before on this connection.
+func (sc *stmtCache) nextName() string {
+    sc.seq++
+    return "serin_stmt_" + strconv.Itoa(sc.seq)
+}
+
+// put records name as prepared for sql, queueing the least recently used
+// statement for deallocation when the cache is full. It reports whether a
+// statement was evicted.
+func (sc *stmtCache) put(sql, name string) bool {
+    sc.bySQL[sql] = sc.lru.PushFront(&cachedStmt{sql: sql, name: name})
+    if sc.lru.Len() <= sc.capacity {
+        return false
+    }
+    oldest := sc.lru.Remove(sc.lru.Back()).(*cachedStmt)
+    delete(sc.bySQL, oldest.sql)
+    sc.evicted = append(sc.evicted, oldest.name)
+    return true
+}
+
+// forget drops the statement called name, queueing it for deallocation, and
+// returns the SQL it was prepared for.
+func (sc *stmtCache) forget(name string) (string, bool) {
+    for el := sc.lru.Front(); el != nil; el = el.Next() {
+        if cs := el.Value.(*cachedStmt); cs.name == name {
+            sc.lru.Remove(el)
+            delete(sc.bySQL, cs.sql)
+            sc.evicted = append(sc.evicted, name)
+            return cs.sql, true
+        }
+    }
+    return "", false
+}
+
+// reset forgets every statement after the server dropped them all. Names
+// keep counting up so they are never reused.
+func (sc *stmtCache) reset() {
+    sc.lru.Init()
+    sc.bySQL = make(map[string]*list.Element)
+    sc.evicted = nil
+}
+
+// statement returns the SQL to hand to pgx for query: the name of a cached
+// prepared statement, or query itself when caching is disabled.
+func (c *serinConn) statement(ctx context.Context, query string) (string, error) {
+    if c.stmts == nil {
+        return query, nil
+    }
+    if err := c.deallocateEvicted(ctx); err != nil {
+        return "", err
+    }
+    if name, ok := c.stmts.get(query); ok {
+        c.connector.metrics.stmtCacheHits.Add(1)
+        return name, nil
+    }
+    c.connector.metrics.stmtCacheMisses.Add(1)
+    name := c.stmts.nextName()
+    sd, err := c.conn.Prepare(ctx, name, query)
+    if err != nil {
+        return "", err
+    }
+    if err := c.resolveDomains(ctx, sd); err != nil {
+        return "", err
+    }
+    if c.stmts.put(query, name) {
+        c.connector.metrics.stmtCacheEvictions.Add(1)
+        if err := c.deallocateEvicted(ctx); err != nil {
+            return "", err
+        }
+    }
+    return name, nil
+}
+
+// deallocateEvicted releases evicted statements on the server. Inside a failed
+// transaction DEALLOCATE would be rejected, so it waits for the next chance.
+func (c *serinConn) deallocateEvicted(ctx context.Context) error {
+    if c.conn.PgConn().TxStatus() == 'E' {
+        return nil
+    }
+    for len(c.stmts.evicted) > 0 {
+        if err := c.conn.Deallocate(ctx, c.stmts.evicted[0]); err != nil {
+            return err
+        }
+        c.stmts.evicted = c.stmts.evicted[1:]
+    }
+    return nil
+}
+
+// isPlanInvalidated reports whether err is the server refusing to run a
+// prepared statement whose result type changed, typically after ALTER TABLE.
+func isPlanInvalidated(err error) bool {
+    var pgErr *pgconn.PgError
+    return errors.As(err, &pgErr) && pgErr.Code == "0A000" && strings.Contains(pgErr.Message, "cached plan must not change result type")
+}
+
+// reprepare handles err from running the cached statement name. If the
+// statement's plan was invalidated it is dropped from the cache and, unless
+// the failure aborted a transaction, prepared again; the new name is returned
+// for the caller to retry with. Inside a transaction the next use prepares it.
+func (c *serinConn) reprepare(ctx context.Context, err error, name string) (string, bool) {
+    if c.stmts == nil || !isPlanInvalidated(err) {
+        return "", false
+    }
+    query, ok := c.stmts.forget(name)
+    if !ok {
+        return "", false
+    }
+    c.connector.metrics.stmtReprepares.Add(1)
+    c.connector.log().DebugContext(ctx, "serin: re-preparing statement after its result type changed", append([]any{slog.String("statement", name), slog.String("query", query)}, metaAttr(ctx)...)...)
+    if c.conn.PgConn().TxStatus() != 'I' {
+        return "", false
+    }
+    newName, err := c.statement(ctx, query)
+    if err != nil {
+        return "", false
+    }
+    return newName, true
+}
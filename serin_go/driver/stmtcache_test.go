@@ -0,0 +1,123 @@
+package driver
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestStmtCacheEvictsLeastRecentlyUsed(t *testing.T) {
+    sc := newStmtCache(2)
+    for _, q := range []string{"SELECT 1", "SELECT 2"} {
+        if sc.put(q, sc.nextName()) {
+            t.Fatalf("evicted while filling the cache with %q", q)
+        }
+    }
+    if name, ok := sc.get("SELECT 1"); !ok || name != "serin_stmt_1" {
+        t.Fatalf("get(SELECT 1) = %q, %v", name, ok)
+    }
+    if !sc.put("SELECT 3", sc.nextName()) {
+        t.Fatal("overflowing put did not evict")
+    }
+    if _, ok := sc.get("SELECT 2"); ok {
+        t.Error("least recently used statement is still cached")
+    }
+    if _, ok := sc.get("SELECT 1"); !ok {
+        t.Error("recently used statement was evicted")
+    }
+    if len(sc.evicted) != 1 || sc.evicted[0] != "serin_stmt_2" {
+        t.Errorf("evicted = %v, want [serin_stmt_2]", sc.evicted)
+    }
+}
+
+func TestStmtCacheDeallocatesOnServer(t *testing.T) {
+    db, c := testConnectorDB(t, WithStatementCacheCapacity(3))
+    db.SetMaxOpenConns(1)
+    for i := 0; i < 10; i++ {
+        if _, err := db.Exec(fmt.Sprintf("SELECT %d", i)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if _, err := db.Exec("SELECT 9"); err != nil {
+        t.Fatal(err)
+    }
+    var prepared int
+    if err := db.QueryRow("SELECT count(*) FROM pg_prepared_statements WHERE name LIKE 'serin_stmt_%'").Scan(&prepared); err != nil {
+        t.Fatal(err)
+    }
+    if prepared != 3 {
+        t.Errorf("server holds %d prepared statements, want 3", prepared)
+    }
+    m := c.Metrics()
+    // The count query itself is the eleventh miss and the eighth eviction.
+    if m.StatementCacheHits != 1 || m.StatementCacheMisses != 11 || m.StatementCacheEvictions != 8 {
+        t.Errorf("unexpected cache metrics %+v", m)
+    }
+}
+
+func TestStmtCacheForget(t *testing.T) {
+    sc := newStmtCache(4)
+    sc.put("SELECT 1", sc.nextName())
+    if q, ok := sc.forget("serin_stmt_1"); !ok || q != "SELECT 1" {
+        t.Fatalf("forget = %q, %v", q, ok)
+    }
+    if _, ok := sc.get("SELECT 1"); ok {
+        t.Error("forgotten statement is still cached")
+    }
+    if len(sc.evicted) != 1 || sc.evicted[0] != "serin_stmt_1" {
+        t.Errorf("evicted = %v, want [serin_stmt_1]", sc.evicted)
+    }
+    if _, ok := sc.forget("serin_stmt_1"); ok {
+        t.Error("statement forgotten twice")
+    }
+}
+
+func TestIsPlanInvalidated(t *testing.T) {
+    if !isPlanInvalidated(&pgconn.PgError{Code: "0A000", Message: "cached plan must not change result type"}) {
+        t.Error("plan invalidation not recognised")
+    }
+    if isPlanInvalidated(&pgconn.PgError{Code: "0A000", Message: "cannot insert multiple commands into a prepared statement"}) {
+        t.Error("other feature errors treated as plan invalidation")
+    }
+}
+
+func TestStmtCacheReprepares(t *testing.T) {
+    db, c := testConnectorDB(t)
+    db.SetMaxOpenConns(1)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_reprepare", "CREATE TABLE serin_reprepare (id int)", "INSERT INTO serin_reprepare VALUES (1)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_reprepare") })
+
+    columns := func() string {
+        rows, err := db.Query("SELECT * FROM serin_reprepare")
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer rows.Close()
+        cols, _ := rows.Columns()
+        for rows.Next() {
+        }
+        if err := rows.Err(); err != nil {
+            t.Fatal(err)
+        }
+        return strings.Join(cols, ",")
+    }
+    if got := columns(); got != "id" {
+        t.Fatalf("columns %s", got)
+    }
+    if _, err := db.Exec("SELECT * FROM serin_reprepare"); err != nil {
+        t.Fatal(err)
+    }
+    mustExec(t, db, "ALTER TABLE serin_reprepare ADD COLUMN note text")
+    if got := columns(); got != "id,note" {
+        t.Errorf("columns after ALTER TABLE %s", got)
+    }
+    mustExec(t, db, "ALTER TABLE serin_reprepare DROP COLUMN note")
+    if _, err := db.Exec("SELECT * FROM serin_reprepare"); err != nil {
+        t.Fatal(err)
+    }
+    if n := c.Metrics().StatementReprepares; n != 2 {
+        t.Errorf("counted %d re-prepares, want 2", n)
+    }
+}
@@ -0,0 +1,189 @@
+package driver
+
+import (
+    "database/sql/driver"
+    "encoding/binary"
+    "fmt"
+    "strconv"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// Type OIDs of xid8, the 64-bit transaction id, which pgx does not register.
+const (
+    xid8OID      = 5069
+    xid8ArrayOID = 271
+)
+
+// TID is a tuple identifier as found in the ctid system column: the heap
+// block number and the item offset within it. ctid also scans into a string
+// using the server's "(block,offset)" form.
+type TID struct {
+    Block  uint32
+    Offset uint16
+}
+
+func (t TID) String() string { return fmt.Sprintf("(%d,%d)", t.Block, t.Offset) }
+
+func (t *TID) Scan(src any) error {
+    var s string
+    switch v := src.(type) {
+    case string:
+        s = v
+    case []byte:
+        s = string(v)
+    case nil:
+        return fmt.Errorf("serin: cannot scan NULL into TID; scan into *TID instead")
+    default:
+        return fmt.Errorf("serin: cannot scan %T into TID", src)
+    }
+    var block, offset uint64
+    if _, err := fmt.Sscanf(s, "(%d,%d)", &block, &offset); err != nil || block > 1<<32-1 || offset > 1<<16-1 {
+        return fmt.Errorf("serin: invalid tid %q", s)
+    }
+    t.Block, t.Offset = uint32(block), uint16(offset)
+    return nil
+}
+
+// sqlValue converts pgx decoded values that database/sql cannot assign to
+// ordinary Go destinations into one of its standard driver value types.
+func sqlValue(v any) any {
+    switch v := v.(type) {
+    case pgtype.TID:
+        if !v.Valid {
+            return nil
+        }
+        return TID{Block: v.BlockNumber, Offset: v.OffsetNumber}.String()
+    }
+    return v
+}
+
+// registerSystemTypes adds the system types pgx lacks to m.
+func registerSystemTypes(m *pgtype.Map) {
+    xid8 := &pgtype.Type{Name: "xid8", OID: xid8OID, Codec: xid8Codec{}}
+    m.RegisterType(xid8)
+    m.RegisterType(&pgtype.Type{Name: "_xid8", OID: xid8ArrayOID, Codec: &pgtype.ArrayCodec{ElementType: xid8}})
+}
+
+// xid8Codec reads and writes xid8 as uint64. Its binary form is the
+// big-endian 64-bit integer, its text form the decimal number.
+//
+// Transaction ids scan into and bind from uint32 for xid (system columns such
+// as xmin and xmax, age()), which pgx handles itself, and uint64 for xid8
+// (pg_current_xact_id() and pg_snapshot functions). xid is a 32-bit counter
+// that wraps around: compare xid values with age() or on the server rather
+// than numerically, since a later transaction can have a smaller xid. xid8
+// carries the wraparound epoch in its upper 32 bits and only ever increases,
+// so xid8 values order correctly; txid_current() returns the same value as
+// int8. database/sql does not bind uint64 values of 1<<63 and above, which no
+// server reaches.
+type xid8Codec struct{}
+
+func (xid8Codec) FormatSupported(format int16) bool {
+    return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+func (xid8Codec) PreferredFormat() int16 { return pgtype.BinaryFormatCode }
+
+func (xid8Codec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+    switch value.(type) {
+    case uint64, uint32, int64, int32, int:
+        return xid8EncodePlan{format: format}
+    case string:
+        if format == pgtype.TextFormatCode {
+            return xid8EncodePlan{format: format}
+        }
+    }
+    return nil
+}
+
+type xid8EncodePlan struct {
+    format int16
+}
+
+func (p xid8EncodePlan) Encode(value any, buf []byte) ([]byte, error) {
+    var n uint64
+    switch v := value.(type) {
+    case uint64:
+        n = v
+    case uint32:
+        n = uint64(v)
+    case int64:
+        if v < 0 {
+            return nil, fmt.Errorf("serin: %d is out of range for xid8", v)
+        }
+        n = uint64(v)
+    case int32:
+        return p.Encode(int64(v), buf)
+    case int:
+        return p.Encode(int64(v), buf)
+    case string:
+        if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+            return nil, fmt.Errorf("serin: invalid xid8 %q", v)
+        }
+        return append(buf, v...), nil
+    }
+    if p.format == pgtype.BinaryFormatCode {
+        return binary.BigEndian.AppendUint64(buf, n), nil
+    }
+    return strconv.AppendUint(buf, n, 10), nil
+}
+
+func (xid8Codec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+    switch target.(type) {
+    case *uint64, *int64, *string:
+        return xid8ScanPlan{format: format}
+    }
+    return nil
+}
+
+type xid8ScanPlan struct {
+    format int16
+}
+
+func (p xid8ScanPlan) Scan(src []byte, dst any) error {
+    if src == nil {
+        return fmt.Errorf("serin: cannot scan NULL into %T", dst)
+    }
+    n, err := decodeXID8(p.format, src)
+    if err != nil {
+        return err
+    }
+    switch d := dst.(type) {
+    case *uint64:
+        *d = n
+    case *int64:
+        if n > 1<<63-1 {
+            return fmt.Errorf("serin: xid8 %d overflows int64", n)
+        }
+        *d = int64(n)
+    case *string:
+        *d = strconv.FormatUint(n, 10)
+    }
+    return nil
+}
+
+func (c xid8Codec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+    return c.DecodeValue(m, oid, format, src)
+}
+
+func (xid8Codec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+    if src == nil {
+        return nil, nil
+    }
+    return decodeXID8(format, src)
+}
+
+func decodeXID8(format int16, src []byte) (uint64, error) {
+    if format == pgtype.BinaryFormatCode {
+        if len(src) != 8 {
+            return 0, fmt.Errorf("serin: invalid length %d for binary xid8", len(src))
+        }
+        return binary.BigEndian.Uint64(src), nil
+    }
+    n, err := strconv.ParseUint(string(src), 10, 64)
+    if err != nil {
+        return 0, fmt.Errorf("serin: invalid xid8 %q", src)
+    }
+    return n, nil
+}
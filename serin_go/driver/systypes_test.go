@@ -0,0 +1,110 @@
+package driver
+
+import (
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestTIDScan(t *testing.T) {
+    var tid TID
+    if err := tid.Scan("(4294967295,12)"); err != nil {
+        t.Fatal(err)
+    }
+    if tid != (TID{Block: 4294967295, Offset: 12}) || tid.String() != "(4294967295,12)" {
+        t.Errorf("parsed %+v", tid)
+    }
+    for _, bad := range []any{"4,12", "(1,70000)", "(-1,2)", 42, nil} {
+        if err := tid.Scan(bad); err == nil {
+            t.Errorf("Scan(%v) succeeded", bad)
+        }
+    }
+    if v := sqlValue(pgtype.TID{BlockNumber: 3, OffsetNumber: 7, Valid: true}); v != "(3,7)" {
+        t.Errorf("sqlValue(tid) = %v", v)
+    }
+}
+
+func TestSystemColumns(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_syscols", "CREATE TABLE serin_syscols (v text)", "INSERT INTO serin_syscols VALUES ('a'), ('b')")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_syscols") })
+
+    rows, err := db.Query("SELECT ctid, ctid, xmin, xmax FROM serin_syscols ORDER BY ctid")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer rows.Close()
+    var n int
+    for rows.Next() {
+        var tid TID
+        var text string
+        var xmin, xmax uint32
+        if err := rows.Scan(&tid, &text, &xmin, &xmax); err != nil {
+            t.Fatal(err)
+        }
+        n++
+        if tid.Block != 0 || int(tid.Offset) != n || text != tid.String() {
+            t.Errorf("row %d: ctid %v / %q", n, tid, text)
+        }
+        if xmin == 0 || xmax != 0 {
+            t.Errorf("row %d: xmin %d xmax %d", n, xmin, xmax)
+        }
+    }
+    if err := rows.Err(); err != nil {
+        t.Fatal(err)
+    }
+    if n != 2 {
+        t.Errorf("read %d rows, want 2", n)
+    }
+}
+
+func TestXID8Codec(t *testing.T) {
+    m := pgtype.NewMap()
+    registerSystemTypes(m)
+    if typ, ok := m.TypeForOID(xid8OID); !ok || typ.Name != "xid8" {
+        t.Fatal("xid8 not registered")
+    }
+    for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+        buf, err := m.Encode(xid8OID, format, uint64(1<<32+7), nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        var n uint64
+        if err := m.Scan(xid8OID, format, buf, &n); err != nil || n != 1<<32+7 {
+            t.Errorf("format %d: got %d, %v", format, n, err)
+        }
+    }
+    if _, err := m.Encode(xid8OID, pgtype.BinaryFormatCode, int64(-1), nil); err == nil {
+        t.Error("negative xid8 encoded")
+    }
+    var s string
+    if err := m.Scan(xid8OID, pgtype.TextFormatCode, []byte("18446744073709551615"), &s); err != nil || s != "18446744073709551615" {
+        t.Errorf("got %q, %v", s, err)
+    }
+}
+
+func TestTransactionIDs(t *testing.T) {
+    db := testDB(t)
+    tx, err := db.Begin()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer tx.Rollback()
+    var txid, full uint64
+    var short uint32
+    if err := tx.QueryRow("SELECT txid_current(), pg_current_xact_id(), pg_current_xact_id()::xid").Scan(&txid, &full, &short); err != nil {
+        t.Fatal(err)
+    }
+    if txid == 0 || full != txid || short != uint32(full) {
+        t.Errorf("txid_current %d, xid8 %d, xid %d", txid, full, short)
+    }
+
+    var back8 uint64
+    var back uint32
+    if err := tx.QueryRow("SELECT $1::xid8, $2::xid", full, short).Scan(&back8, &back); err != nil {
+        t.Fatal(err)
+    }
+    if back8 != full || back != short {
+        t.Errorf("round tripped %d, %d", back8, back)
+    }
+}
@@ -0,0 +1,78 @@
+package driver
+
+import (
+    "database/sql/driver"
+    "fmt"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+// WithTimeLocation makes timestamptz values, including elements of
+// timestamptz arrays, come back as time.Time in loc whatever the server's
+// TimeZone setting, so the same instant always has the same Location and
+// wall clock. The instant itself never changes. timestamp values without a
+// zone are not converted: they keep their stored wall clock and are returned
+// in UTC, since they carry no offset to convert from.
+func WithTimeLocation(loc *time.Location) Option {
+    return func(c *Connector) { c.timeLocation = loc }
+}
+
+// registerTimeLocation replaces the timestamptz codecs of m with ones that
+// return times in loc.
+func registerTimeLocation(m *pgtype.Map, loc *time.Location) {
+    tstz := &pgtype.Type{Name: "timestamptz", OID: pgtype.TimestamptzOID, Codec: locationCodec{loc: loc}}
+    m.RegisterType(tstz)
+    m.RegisterType(&pgtype.Type{Name: "_timestamptz", OID: pgtype.TimestamptzArrayOID, Codec: &pgtype.ArrayCodec{ElementType: tstz}})
+}
+
+// locationCodec is the timestamptz codec with decoded times moved into loc.
+type locationCodec struct {
+    pgtype.TimestamptzCodec
+    loc *time.Location
+}
+
+func (c locationCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+    if _, ok := target.(*time.Time); ok {
+        if next := c.TimestamptzCodec.PlanScan(m, oid, format, (*pgtype.Timestamptz)(nil)); next != nil {
+            return locationScanPlan{next: next, loc: c.loc}
+        }
+    }
+    return c.TimestamptzCodec.PlanScan(m, oid, format, target)
+}
+
+func (c locationCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+    v, err := c.TimestamptzCodec.DecodeDatabaseSQLValue(m, oid, format, src)
+    if t, ok := v.(time.Time); ok {
+        return t.In(c.loc), err
+    }
+    return v, err
+}
+
+func (c locationCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+    v, err := c.TimestamptzCodec.DecodeValue(m, oid, format, src)
+    if t, ok := v.(time.Time); ok {
+        return t.In(c.loc), err
+    }
+    return v, err
+}
+
+type locationScanPlan struct {
+    next pgtype.ScanPlan
+    loc  *time.Location
+}
+
+func (p locationScanPlan) Scan(src []byte, dst any) error {
+    var ts pgtype.Timestamptz
+    if err := p.next.Scan(src, &ts); err != nil {
+        return err
+    }
+    if !ts.Valid {
+        return fmt.Errorf("serin: cannot scan NULL into %T", dst)
+    }
+    if ts.InfinityModifier != pgtype.Finite {
+        return fmt.Errorf("serin: cannot scan %s timestamptz into %T", ts.InfinityModifier, dst)
+    }
+    *dst.(*time.Time) = ts.Time.In(p.loc)
+    return nil
+}
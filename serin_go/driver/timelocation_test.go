@@ -0,0 +1,84 @@
+package driver
+
+import (
+    "context"
+    "testing"
+    "time"
+    _ "time/tzdata"
+
+    "github.com/jackc/pgx/v5/pgtype"
+)
+
+func newYork(t *testing.T) *time.Location {
+    loc, err := time.LoadLocation("America/New_York")
+    if err != nil {
+        t.Fatal(err)
+    }
+    return loc
+}
+
+func TestLocationCodec(t *testing.T) {
+    loc := newYork(t)
+    m := pgtype.NewMap()
+    registerTimeLocation(m, loc)
+    typ, _ := m.TypeForOID(pgtype.TimestamptzOID)
+    for _, tc := range []struct {
+        at   time.Time
+        zone string
+    }{
+        {time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), "EST"},
+        {time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC), "EDT"},
+    } {
+        for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+            buf, err := m.Encode(pgtype.TimestamptzOID, format, tc.at, nil)
+            if err != nil {
+                t.Fatal(err)
+            }
+            var scanned time.Time
+            if err := m.Scan(pgtype.TimestamptzOID, format, buf, &scanned); err != nil {
+                t.Fatal(err)
+            }
+            v, err := typ.Codec.DecodeValue(m, pgtype.TimestamptzOID, format, buf)
+            if err != nil {
+                t.Fatal(err)
+            }
+            for _, got := range []time.Time{scanned, v.(time.Time)} {
+                if zone, _ := got.Zone(); got.Location() != loc || zone != tc.zone || !got.Equal(tc.at) {
+                    t.Errorf("format %d: got %v in %v, want %s in %v", format, got, got.Location(), tc.zone, loc)
+                }
+            }
+        }
+    }
+    var arr []time.Time
+    buf, _ := m.Encode(pgtype.TimestamptzArrayOID, pgtype.BinaryFormatCode, []time.Time{time.Unix(0, 0)}, nil)
+    if err := m.Scan(pgtype.TimestamptzArrayOID, pgtype.BinaryFormatCode, buf, &arr); err != nil || len(arr) != 1 || arr[0].Location() != loc {
+        t.Errorf("array element %v, %v", arr, err)
+    }
+}
+
+func TestTimeLocation(t *testing.T) {
+    loc := newYork(t)
+    db, _ := testConnectorDB(t, WithTimeLocation(loc))
+    conn, err := db.Conn(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    mustExecConn(t, conn, "SET TimeZone = 'Asia/Tokyo'")
+    var winter, summer, naive time.Time
+    err = conn.QueryRowContext(context.Background(), "SELECT '2024-01-15 12:00Z'::timestamptz, '2024-07-15 12:00Z'::timestamptz, '2024-07-15 12:00'::timestamp").Scan(&winter, &summer, &naive)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, got := range []time.Time{winter, summer} {
+        if got.Location() != loc {
+            t.Errorf("%v returned in %v", got, got.Location())
+        }
+    }
+    if w, s := winter.Format("15:04 MST"), summer.Format("15:04 MST"); w != "07:00 EST" || s != "08:00 EDT" {
+        t.Errorf("got %s and %s", w, s)
+    }
+    if naive.Location() != time.UTC || naive.Hour() != 12 {
+        t.Errorf("timestamp returned as %v", naive)
+    }
+}
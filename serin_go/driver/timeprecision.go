@@ -0,0 +1,59 @@
+package driver
+
+import (
+    "errors"
+    "fmt"
+    "reflect"
+    "time"
+)
+
+// ErrTimePrecision is returned under WithStrictTimePrecision for a time.Time
+// argument that has nanoseconds the server cannot store.
+var ErrTimePrecision = errors.New("serin: time has sub-microsecond precision")
+
+// WithStrictTimePrecision makes binding a time.Time (or a slice of them) with
+// a non-zero sub-microsecond part fail with ErrTimePrecision. timestamp,
+// timestamptz and time columns have microsecond resolution while time.Time
+// has nanoseconds, so by default such values are silently truncated to the
+// earlier microsecond when sent in binary; with the simple protocol they are
+// sent as text and the server rounds them to the nearest microsecond instead.
+// Call t.Truncate(time.Microsecond) or t.Round(time.Microsecond) to choose
+// explicitly.
+func WithStrictTimePrecision(strict bool) Option {
+    return func(c *Connector) { c.strictTime = strict }
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// checkTimePrecision returns ErrTimePrecision for the first argument that is
+// a time.Time, or a slice of them, with sub-microsecond precision.
+func checkTimePrecision(args []any) error {
+    for i, a := range args {
+        switch v := a.(type) {
+        case time.Time:
+            if err := timePrecision(i, v); err != nil {
+                return err
+            }
+            continue
+        case nil:
+            continue
+        }
+        rv := reflect.ValueOf(a)
+        if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem() != timeType {
+            continue
+        }
+        for j := 0; j < rv.Len(); j++ {
+            if err := timePrecision(i, rv.Index(j).Interface().(time.Time)); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+func timePrecision(i int, t time.Time) error {
+    if t.Nanosecond()%1000 != 0 {
+        return fmt.Errorf("%w: argument $%d is %s", ErrTimePrecision, i+1, t.Format(time.RFC3339Nano))
+    }
+    return nil
+}
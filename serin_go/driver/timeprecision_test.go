@@ -0,0 +1,41 @@
+package driver
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+var nanoTime = time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+
+func TestCheckTimePrecision(t *testing.T) {
+    micro := nanoTime.Truncate(time.Microsecond)
+    if err := checkTimePrecision([]any{"x", nil, micro, []time.Time{micro}}); err != nil {
+        t.Errorf("microsecond times rejected: %v", err)
+    }
+    for _, args := range [][]any{{nanoTime}, {micro, []time.Time{micro, nanoTime}}, {[1]time.Time{nanoTime}}} {
+        if err := checkTimePrecision(args); !errors.Is(err, ErrTimePrecision) {
+            t.Errorf("%v: got %v", args, err)
+        }
+    }
+}
+
+func TestTimePrecision(t *testing.T) {
+    for _, strict := range []bool{false, true} {
+        db, _ := testConnectorDB(t, WithStrictTimePrecision(strict))
+        var got time.Time
+        err := db.QueryRow("SELECT $1::timestamptz", nanoTime).Scan(&got)
+        if strict {
+            if !errors.Is(err, ErrTimePrecision) {
+                t.Errorf("strict: got %v, %v; want ErrTimePrecision", got, err)
+            }
+            err = db.QueryRow("SELECT $1::timestamptz", nanoTime.Truncate(time.Microsecond)).Scan(&got)
+        }
+        if err != nil {
+            t.Fatal(err)
+        }
+        if want := nanoTime.Truncate(time.Microsecond); !got.Equal(want) {
+            t.Errorf("strict %v: read back %s, want %s", strict, got.Format(time.RFC3339Nano), want.Format(time.RFC3339Nano))
+        }
+    }
+}
@@ -0,0 +1,138 @@
+package driver
+
+import "strings"
+
+// tokenKind classifies the pieces of SQL text that the query rewriters must
+// tell apart: text inside literals, quoted identifiers and comments is never
+// rewritten or split, and only placeholders that stand on their own are
+// bound. Everything else is tokenOther, one byte at a time.
+type tokenKind int
+
+const (
+    tokenOther        tokenKind = iota
+    tokenSpace                  // run of whitespace
+    tokenLineComment            // -- comment, without its newline
+    tokenBlockComment           // /* */ comment, which may nest
+    tokenString                 // '...' or E'...' literal
+    tokenQuotedIdent            // "..." identifier
+    tokenDollarString           // $tag$...$tag$ literal
+    tokenPlaceholder            // $n parameter
+    tokenWord                   // keyword, identifier or number
+    tokenSemicolon
+)
+
+// nextToken returns the kind and length of the token s starts with; s must
+// not be empty. Literals and comments that are not closed run to the end of
+// s, so that every rewriter treats the rest of the text as inert, as the
+// server would before rejecting it.
+func nextToken(s string) (tokenKind, int) {
+    c := s[0]
+    switch {
+    case isSpaceByte(c):
+        n := 1
+        for n < len(s) && isSpaceByte(s[n]) {
+            n++
+        }
+        return tokenSpace, n
+    case strings.HasPrefix(s, "--"):
+        if n := strings.IndexByte(s, '\n'); n >= 0 {
+            return tokenLineComment, n
+        }
+        return tokenLineComment, len(s)
+    case strings.HasPrefix(s, "/*"):
+        return tokenBlockComment, blockCommentLen(s)
+    case c == '\'':
+        return tokenString, quotedLen(s, false)
+    case (c == 'E' || c == 'e') && len(s) > 1 && s[1] == '\'':
+        return tokenString, 1 + quotedLen(s[1:], true)
+    case c == '"':
+        return tokenQuotedIdent, quotedLen(s, false)
+    case c == '$':
+        if tag := dollarTagLen(s); tag > 0 {
+            if n := strings.Index(s[tag:], s[:tag]); n >= 0 {
+                return tokenDollarString, 2*tag + n
+            }
+            return tokenDollarString, len(s)
+        }
+        n := 1
+        for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+            n++
+        }
+        if n > 1 {
+            return tokenPlaceholder, n
+        }
+    case c == ';':
+        return tokenSemicolon, 1
+    case isIdentByte(c):
+        // Identifiers may contain $, so "a$1" is one word, not a placeholder.
+        n := 1
+        for n < len(s) && (isIdentByte(s[n]) || s[n] == '$') {
+            n++
+        }
+        return tokenWord, n
+    }
+    return tokenOther, 1
+}
+
+// blockCommentLen returns the length of the /* */ comment s starts with,
+// counting nested comments.
+func blockCommentLen(s string) int {
+    depth, i := 1, 2
+    for i < len(s) && depth > 0 {
+        switch {
+        case strings.HasPrefix(s[i:], "/*"):
+            depth++
+            i += 2
+        case strings.HasPrefix(s[i:], "*/"):
+            depth--
+            i += 2
+        default:
+            i++
+        }
+    }
+    return i
+}
+
+// quotedLen returns the length of the literal or identifier s starts with,
+// closed by the first undoubled copy of its opening quote. With backslash,
+// as in E'...' strings, a backslash escapes the next byte.
+func quotedLen(s string, backslash bool) int {
+    q := s[0]
+    for i := 1; i < len(s); i++ {
+        switch {
+        case backslash && s[i] == '\\':
+            i++
+        case s[i] == q:
+            if i+1 < len(s) && s[i+1] == q {
+                i++
+                continue
+            }
+            return i + 1
+        }
+    }
+    return len(s)
+}
+
+// dollarTagLen returns the length of the opening "$tag$" of a dollar-quoted
+// string at the start of s, or 0 when there is none.
+func dollarTagLen(s string) int {
+    for i := 1; i < len(s); i++ {
+        switch c := s[i]; {
+        case c == '$':
+            return i + 1
+        case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+        case c >= '0' && c <= '9' && i > 1:
+        default:
+            return 0
+        }
+    }
+    return 0
+}
+
+func isIdentByte(c byte) bool {
+    return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func isSpaceByte(c byte) bool {
+    return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
@@ -0,0 +1,110 @@
+package driver
+
+import (
+    "reflect"
+    "strconv"
+    "strings"
+    "testing"
+)
+
+// trickySQL seeds FuzzTokenizer and the table tests below.
+var trickySQL = []string{
+    "SELECT $1, $2::int FROM t WHERE a = $10",
+    `SELECT 'it''s $1', E'\'$1;', e'\\', "col$1""", $1`,
+    "SELECT $$ $1; $$, $fn$ $$ $1 $fn$, $a1$x$a1$, $1$",
+    "SELECT a$1, a$b$c, _$2, é$3 FROM t",
+    "SELECT 1 /* $1 /* nested; $1 */ still $1 */ + $1 -- $1;\n; $2",
+    "SELECT '$1 unterminated; ",
+    "/* unterminated /* nested */ $1",
+    "SELECT $fn$ unterminated $1;",
+    "CREATE FUNCTION f() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT CASE WHEN true THEN 1 END; END; SELECT $1",
+    "SELECT U&'d\\0061t$1', B'101', X'1f' -- end",
+    "$", "$$", "$1", "'", "E'", "\"", "--", "/*", "/*/", ";;", "\x80$1\xff",
+}
+
+func FuzzTokenizer(f *testing.F) {
+    for _, s := range trickySQL {
+        f.Add(s)
+    }
+    f.Fuzz(func(t *testing.T, s string) {
+        var b strings.Builder
+        prev := tokenOther
+        for i := 0; i < len(s); {
+            kind, n := nextToken(s[i:])
+            if n <= 0 || i+n > len(s) {
+                t.Fatalf("token at %d of %q has length %d", i, s, n)
+            }
+            tok := s[i : i+n]
+            if kind == tokenPlaceholder {
+                if tok[0] != '$' || len(tok) < 2 || strings.Trim(tok[1:], "0123456789") != "" {
+                    t.Fatalf("placeholder token %q in %q", tok, s)
+                }
+                if prev == tokenWord {
+                    t.Fatalf("placeholder %q inside a word in %q", tok, s)
+                }
+            }
+            b.WriteString(tok)
+            prev = kind
+            i += n
+        }
+        if b.String() != s {
+            t.Fatalf("tokens of %q rebuild %q", s, b.String())
+        }
+
+        if got := rewritePlaceholders(s, func(int) (string, bool) { return "", false }); got != s {
+            t.Fatalf("declined rewrite changed %q into %q", s, got)
+        }
+        renumbered := rewritePlaceholders(s, func(n int) (string, bool) { return "$" + strconv.Itoa(n), true })
+        if got := rewritePlaceholders(renumbered, func(n int) (string, bool) { return "$" + strconv.Itoa(n), true }); got != renumbered {
+            t.Fatalf("renumbering %q is not stable: %q then %q", s, renumbered, got)
+        }
+
+        rest := skipSpaceAndComments(s)
+        if !strings.HasSuffix(s, rest) {
+            t.Fatalf("skipSpaceAndComments(%q) = %q", s, rest)
+        }
+        if rest != "" {
+            if kind, _ := nextToken(rest); kind == tokenSpace || kind == tokenLineComment || kind == tokenBlockComment {
+                t.Fatalf("skipSpaceAndComments(%q) left %q", s, rest)
+            }
+        }
+
+        lines := strings.Count(s, "\n") + 1
+        for _, st := range splitScript(s) {
+            if !strings.Contains(s, st.sql) || st.line < 1 || st.line > lines {
+                t.Fatalf("statement %q at line %d not in %q", st.sql, st.line, s)
+            }
+            if again := splitScript(st.sql); len(again) != 1 || again[0] != (scriptStatement{sql: st.sql, line: 1}) {
+                t.Fatalf("statement %q of %q splits again into %q", st.sql, s, again)
+            }
+        }
+    })
+}
+
+func TestNextTokenKinds(t *testing.T) {
+    kinds := func(s string) []tokenKind {
+        var out []tokenKind
+        for i := 0; i < len(s); {
+            kind, n := nextToken(s[i:])
+            if kind != tokenSpace {
+                out = append(out, kind)
+            }
+            i += n
+        }
+        return out
+    }
+    for _, tc := range []struct {
+        sql  string
+        want []tokenKind
+    }{
+        {"SELECT $1;", []tokenKind{tokenWord, tokenPlaceholder, tokenSemicolon}},
+        {`E'a\'b' 'c''d' "e""f"`, []tokenKind{tokenString, tokenString, tokenQuotedIdent}},
+        {"$$a$$ $t$ $$ $t$ $1$", []tokenKind{tokenDollarString, tokenDollarString, tokenPlaceholder, tokenOther}},
+        {"a$1 /* x /* y */ */ -- z", []tokenKind{tokenWord, tokenBlockComment, tokenLineComment}},
+        {"x::int", []tokenKind{tokenWord, tokenOther, tokenOther, tokenWord}},
+    } {
+        if got := kinds(tc.sql); !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("%s: got %v, want %v", tc.sql, got, tc.want)
+        }
+    }
+}
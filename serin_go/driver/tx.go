@@ -0,0 +1,67 @@
+package driver
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ErrRawTxControl is returned when BEGIN, COMMIT, ROLLBACK and friends are sent
+// as plain statements. database/sql cannot see such transactions, so the
+// connection could return to the pool mid-transaction; use db.BeginTx instead.
+var ErrRawTxControl = errors.New("serin: transaction control statements must go through db.Begin/BeginTx and Tx.Commit/Rollback")
+
+func (c *serinConn) Begin() (driver.Tx, error) {
+    return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *serinConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+    var txOpts pgx.TxOptions
+    switch sql.IsolationLevel(opts.Isolation) {
+    case sql.LevelDefault:
+    case sql.LevelReadUncommitted:
+        txOpts.IsoLevel = pgx.ReadUncommitted
+    case sql.LevelReadCommitted:
+        txOpts.IsoLevel = pgx.ReadCommitted
+    case sql.LevelRepeatableRead, sql.LevelSnapshot:
+        txOpts.IsoLevel = pgx.RepeatableRead
+    case sql.LevelSerializable:
+        txOpts.IsoLevel = pgx.Serializable
+    default:
+        return nil, fmt.Errorf("serin: unsupported isolation level %s", sql.IsolationLevel(opts.Isolation))
+    }
+    if opts.ReadOnly {
+        txOpts.AccessMode = pgx.ReadOnly
+    }
+    snapshot := snapshotFrom(ctx)
+    if snapshot != "" && txOpts.IsoLevel == "" {
+        txOpts.IsoLevel = pgx.RepeatableRead
+    }
+    tx, err := c.conn.BeginTx(ctx, txOpts)
+    if err != nil {
+        return nil, err
+    }
+    c.txMeta = nil
+    c.onNotice = noticeHandler(ctx)
+    if snapshot != "" {
+        if _, err := tx.Exec(ctx, "SET TRANSACTION SNAPSHOT "+quoteLiteral(snapshot)); err != nil {
+            tx.Rollback(ctx)
+            return nil, fmt.Errorf("serin: importing snapshot %s: %w", snapshot, err)
+        }
+    }
+    return &serinTx{tx: tx}, nil
+}
+
+// serinTx implements driver.Tx
+
+type serinTx struct {
+    tx pgx.Tx
+}
+
+func (t *serinTx) Commit() error { return t.tx.Commit(context.Background()) }
+
+func (t *serinTx) Rollback() error { return t.tx.Rollback(context.Background()) }
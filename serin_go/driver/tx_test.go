@@ -0,0 +1,60 @@
+package driver
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestIsTxControl(t *testing.T) {
+    for q, want := range map[string]bool{
+        "BEGIN":                                true,
+        "  begin isolation level serializable": true,
+        "/* note */ START TRANSACTION":         true,
+        "-- comment\nCOMMIT":                   true,
+        "commit work":                          true,
+        "END":                                  true,
+        "ROLLBACK":                             true,
+        "abort":                                true,
+        "ROLLBACK TO SAVEPOINT a":              false,
+        "COMMIT PREPARED 'gx'":                 false,
+        "SAVEPOINT a":                          false,
+        "SELECT 'BEGIN'":                       false,
+        "BEGINNING":                            false,
+        "":                                     false,
+    } {
+        if got := isTxControl(q); got != want {
+            t.Errorf("isTxControl(%q) = %v, want %v", q, got, want)
+        }
+    }
+}
+
+func TestRawTxControlRejected(t *testing.T) {
+    db := testDB(t)
+    mustExec(t, db, "DROP TABLE IF EXISTS serin_raw_tx", "CREATE TABLE serin_raw_tx (id int)")
+    t.Cleanup(func() { db.Exec("DROP TABLE serin_raw_tx") })
+
+    if _, err := db.Exec("BEGIN"); !errors.Is(err, ErrRawTxControl) {
+        t.Fatalf("raw BEGIN: got %v, want ErrRawTxControl", err)
+    }
+    if _, err := db.Exec("COMMIT"); !errors.Is(err, ErrRawTxControl) {
+        t.Fatalf("raw COMMIT: got %v, want ErrRawTxControl", err)
+    }
+
+    tx, err := db.Begin()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := tx.Exec("INSERT INTO serin_raw_tx VALUES (1)"); err != nil {
+        t.Fatal(err)
+    }
+    if err := tx.Commit(); err != nil {
+        t.Fatal(err)
+    }
+    var n int
+    if err := db.QueryRow("SELECT count(*) FROM serin_raw_tx").Scan(&n); err != nil {
+        t.Fatal(err)
+    }
+    if n != 1 {
+        t.Errorf("got %d rows after commit, want 1", n)
+    }
+}
@@ -2,6 +2,11 @@ module github.com/SeleniaProject/serin-go
 
 go 1.22
 
+require github.com/jackc/pgx/v5 v5.4.0
+
 require (
-	github.com/jackc/pgx/v5 v5.4.0 // indirect
-) 
\ No newline at end of file
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	golang.org/x/crypto v0.9.0 // indirect
+	golang.org/x/text v0.9.0 // indirect
+)